@@ -0,0 +1,68 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+
+    "shuru-hoja/internal/browse"
+    "shuru-hoja/internal/snapshot"
+    "shuru-hoja/internal/ui"
+)
+
+// runBrowse implements "browse [flags] [path...]", a full-screen browser
+// over a fresh scan of the paths or over a saved snapshot.
+func runBrowse(args []string) error {
+    fs, configFile := newFlagSet("browse")
+    var paths pathList
+    fs.Var(&paths, "path", "Scan this path; may be given several times (default /)")
+    from := fs.String("from", "", "Browse this snapshot file, or \"latest\" or \"previous\" from the store, instead of scanning")
+    export := fs.String("export", "review.csv", "Write the marked entries to this file")
+    fs.Bool("quick", false, "Limit depth and skip duplicate hashing")
+    fs.Int("max-depth", 0, "Do not descend more than this many levels (0 = unlimited)")
+    fs.Bool("apparent-size", false, "Report apparent sizes instead of disk usage")
+    fs.Bool("one-file-system", false, "Stay on the filesystem of each scanned path")
+    fs.Bool("x", false, "Shorthand for --one-file-system")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    // Fail before a long scan rather than after it
+    if !browse.IsTerminal(os.Stdin) || !browse.IsTerminal(os.Stdout) {
+        return fmt.Errorf("browse needs an interactive terminal")
+    }
+
+    cfg, err := loadConfig(fs, *configFile)
+    if err != nil {
+        return fmt.Errorf("failed to load config: %w", err)
+    }
+
+    var snap *snapshot.Snapshot
+    if *from != "" {
+        if len(paths) > 0 || fs.NArg() > 0 {
+            return fmt.Errorf("browse: give either --from or paths to scan")
+        }
+        store := snapshot.Store{Dir: cfg.Snapshot.Dir, Keep: cfg.Snapshot.Keep}
+        file, err := store.Resolve(*from)
+        if err != nil {
+            return err
+        }
+        if snap, err = snapshot.Load(file); err != nil {
+            return err
+        }
+    } else {
+        roots, err := scanRoots(append(paths, fs.Args()...))
+        if err != nil {
+            return err
+        }
+        fmt.Fprintf(os.Stderr, "Scanning %v...\n", roots)
+        if _, snap, err = performScan(context.Background(), cfg, roots, nil, true); err != nil {
+            return err
+        }
+    }
+
+    if !cfg.Output.Color {
+        ui.DisableColor()
+    }
+    return browse.Run(snap, *export)
+}
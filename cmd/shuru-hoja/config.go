@@ -0,0 +1,43 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "text/tabwriter"
+)
+
+// runConfig implements "config show [--origin]" and "config check".
+func runConfig(args []string) error {
+    if len(args) == 0 || (args[0] != "show" && args[0] != "check") {
+        return fmt.Errorf("usage: shuru-hoja config show [--origin] | config check")
+    }
+
+    fs, configFile := newFlagSet("config " + args[0])
+    origin := new(bool)
+    if args[0] == "show" {
+        fs.BoolVar(origin, "origin", false, "Show which layer each value comes from")
+    }
+    if err := fs.Parse(args[1:]); err != nil {
+        return err
+    }
+
+    cfg, err := loadConfig(fs, *configFile)
+    if err != nil {
+        return err
+    }
+
+    if args[0] == "check" {
+        fmt.Println("Configuration OK")
+        return nil
+    }
+
+    w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+    for _, v := range cfg.Values() {
+        if *origin {
+            fmt.Fprintf(w, "%s = %s\t# %s\n", v.Name, v.Value, v.Origin)
+        } else {
+            fmt.Fprintf(w, "%s = %s\n", v.Name, v.Value)
+        }
+    }
+    return w.Flush()
+}
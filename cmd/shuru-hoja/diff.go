@@ -0,0 +1,78 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "shuru-hoja/internal/config"
+    "shuru-hoja/internal/snapshot"
+    "shuru-hoja/internal/ui"
+    "shuru-hoja/pkg/types"
+)
+
+// runDiff implements "diff [flags] [SNAP_A [SNAP_B]]", which shows what
+// changed between two saved scans. Snapshots are files or "latest" and
+// "previous" from the snapshot store, which are also the defaults.
+func runDiff(args []string) error {
+    fs, configFile := newFlagSet("diff")
+    fs.String("format", "table", "Output format: table, json, ndjson, csv, tsv, html, markdown or prometheus")
+    limit := fs.Int("limit", -1, "List at most this many paths per section (0 = all, default output.max_results)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if fs.NArg() > 2 {
+        return fmt.Errorf("usage: shuru-hoja diff [--format F] [--limit N] [SNAP_A [SNAP_B]]")
+    }
+
+    cfg, err := loadConfig(fs, *configFile)
+    if err != nil {
+        return fmt.Errorf("failed to load config: %w", err)
+    }
+    if *limit < 0 {
+        *limit = cfg.Output.MaxResults
+    }
+
+    names := []string{"previous", "latest"}
+    copy(names, fs.Args())
+    store := snapshot.Store{Dir: cfg.Snapshot.Dir, Keep: cfg.Snapshot.Keep}
+
+    var snaps [2]*snapshot.Snapshot
+    var files [2]string
+    for i, name := range names {
+        if files[i], err = store.Resolve(name); err != nil {
+            return err
+        }
+        if snaps[i], err = snapshot.Load(files[i]); err != nil {
+            return err
+        }
+    }
+
+    diff := snapshot.Compare(snaps[0], snaps[1], *limit)
+    diff.From.File = files[0]
+    diff.To.File = files[1]
+
+    return renderDiff(diff, cfg)
+}
+
+// renderDiff writes a diff in the configured format.
+func renderDiff(diff *types.Diff, cfg *config.Config) error {
+    switch cfg.Output.Format {
+    case "json":
+        return ui.WriteDiffJSON(os.Stdout, diff)
+    case "ndjson":
+        return ui.WriteDiffNDJSON(os.Stdout, diff)
+    case "prometheus":
+        return ui.WriteDiffPrometheus(os.Stdout, diff, cfg.Output.PrometheusTopPaths)
+    case "markdown":
+        return ui.WriteDiffMarkdown(os.Stdout, diff)
+    case "html":
+        return ui.WriteDiffHTML(os.Stdout, diff)
+    case "csv":
+        return ui.WriteDiffCSV(os.Stdout, diff, ',')
+    case "tsv":
+        return ui.WriteDiffCSV(os.Stdout, diff, '\t')
+    default:
+        ui.RenderDiff(diff, cfg)
+        return nil
+    }
+}
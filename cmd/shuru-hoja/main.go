@@ -1,67 +1,145 @@
 package main
 
 import (
-    "context"
+    "errors"
+    "flag"
     "fmt"
     "os"
-    "os/signal"
-    "syscall"
-    "time"
+    "strings"
 
-    "shuru-hoja/internal/analyzer"
-    "shuru-hoja/internal/scanner"
-    "shuru-hoja/internal/ui"
     "shuru-hoja/internal/config"
-    "shuru-hoja/pkg/types"
 )
 
+var version = "1.0.0"
+
+// command is one subcommand; run receives the arguments after its name.
+type command struct {
+    name    string
+    summary string
+    run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+    // Assigned here because runHelp refers back to the table
+    commands = []command{
+        {"scan", "Scan one or more paths and report findings (default)", runScan},
+        {"serve", "Scan on a schedule and serve the results over a local HTTP API", runServe},
+        {"report", "Render a saved scan result", runReport},
+        {"browse", "Browse the directory tree and findings of a scan or snapshot", runBrowse},
+        {"diff", "Compare two scans", runDiff},
+        {"config", "Show or check the effective configuration", runConfig},
+        {"version", "Print the version", runVersion},
+        {"help", "Show this help", runHelp},
+    }
+}
+
+// flagSettings maps command-line flags onto the settings they override.
+// Flags are the last configuration layer and only apply when given.
+var flagSettings = map[string]string{
+    "apparent-size":   "output.apparent_size",
+    "one-file-system": "general.one_file_system",
+    "x":               "general.one_file_system",
+    "max-depth":       "general.max_depth",
+    "quick":           "general.quick",
+    "format":          "output.format",
+    "listen":          "daemon.listen",
+    "interval":        "daemon.interval_minutes",
+    "state-file":      "daemon.state_file",
+    "snapshot":        "snapshot.enabled",
+    "docker":          "docker.enabled",
+}
+
 func main() {
-    // Setup signal handling for graceful shutdown
-    ctx, cancel := context.WithCancel(context.Background())
-    defer cancel()
-
-    sigChan := make(chan os.Signal, 1)
-    signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-    go func() {
-        <-sigChan
-        cancel()
-        fmt.Println("\n\nScan interrupted by user. Exiting safely...")
-        os.Exit(0)
-    }()
-
-    // Start analysis
-    if err := runAnalysis(ctx); err != nil {
-        fmt.Printf("Error: %v\n", err)
+    args := os.Args[1:]
+
+    // Without a subcommand the arguments are those of scan, so that
+    // "shuru-hoja --path /tmp" keeps working
+    run := runScan
+    if len(args) > 0 {
+        switch args[0] {
+        case "--version", "-version":
+            run, args = runVersion, args[1:]
+        case "--help", "-help", "-h":
+            run, args = runHelp, args[1:]
+        case "--check-config", "-check-config":
+            run, args = runConfig, append([]string{"check"}, args[1:]...)
+        default:
+            for _, cmd := range commands {
+                if cmd.name == args[0] {
+                    run, args = cmd.run, args[1:]
+                    break
+                }
+            }
+        }
+    }
+
+    if err := run(args); err != nil {
+        if !errors.Is(err, flag.ErrHelp) {
+            fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        }
         os.Exit(1)
     }
 }
 
-func runAnalysis(ctx context.Context) error {
-    startTime := time.Now()
-    
-    // Load configuration
-    cfg, err := config.Load()
+func runVersion(args []string) error {
+    fmt.Printf("shuru hoja v%s\n", version)
+    return nil
+}
+
+func runHelp(args []string) error {
+    fmt.Println("Usage: shuru-hoja [command] [flags] [path...]")
+    fmt.Println()
+    fmt.Println("Commands:")
+    for _, cmd := range commands {
+        fmt.Printf("  %-9s %s\n", cmd.name, cmd.summary)
+    }
+    fmt.Println()
+    fmt.Println(`Run "shuru-hoja <command> --help" for the flags of a command.`)
+    return nil
+}
+
+// newFlagSet creates the flag set of a subcommand with the flags every
+// command shares.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+    fs := flag.NewFlagSet("shuru-hoja "+name, flag.ContinueOnError)
+    configFile := fs.String("config", "", "Read this configuration file after the system and user ones")
+    return fs, configFile
+}
+
+// loadConfig loads every configuration layer and applies the flags given
+// on the command line on top.
+func loadConfig(fs *flag.FlagSet, file string) (*config.Config, error) {
+    cfg, err := config.Load(file)
     if err != nil {
-        return fmt.Errorf("failed to load config: %w", err)
+        return nil, err
+    }
+    for _, warning := range cfg.Warnings() {
+        fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
     }
 
-    // Initialize scanner
-    scanner := scanner.NewConcurrentScanner(cfg.MaxWorkers)
-    
-    // Initialize analyzer with detection rules
-    analyzer := analyzer.NewAnalyzer(scanner, cfg)
-    
-    // Start scanning
-    ui.ShowWelcome()
-    
-    results, err := analyzer.Analyze(ctx, "/")
+    fs.Visit(func(f *flag.Flag) {
+        name, ok := flagSettings[f.Name]
+        if ok && err == nil {
+            err = cfg.Set(name, f.Value.String(), "flag --"+f.Name)
+        }
+    })
     if err != nil {
-        return fmt.Errorf("analysis failed: %w", err)
+        return nil, err
     }
-    
-    // Render results
-    duration := time.Since(startTime)
-    ui.RenderResults(results, duration, cfg)
-    
+
+    return cfg, nil
+}
+
+// pathList collects a flag that may be given several times.
+type pathList []string
+
+func (p *pathList) String() string {
+    return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(value string) error {
+    *p = append(*p, value)
     return nil
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "shuru-hoja/internal/config"
+    "shuru-hoja/internal/ui"
+    "shuru-hoja/pkg/types"
+)
+
+// runReport implements "report [--format F] FILE", which renders a report
+// saved with --format json again, in any format.
+func runReport(args []string) error {
+    fs, configFile := newFlagSet("report")
+    fs.String("format", "table", "Output format: table, json, ndjson, csv, tsv, html, markdown or prometheus")
+    limit := fs.Int("limit", 0, "Write at most this many findings in csv and tsv (0 = all)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if fs.NArg() != 1 {
+        return fmt.Errorf("usage: shuru-hoja report [--format F] [--limit N] FILE")
+    }
+
+    cfg, err := loadConfig(fs, *configFile)
+    if err != nil {
+        return fmt.Errorf("failed to load config: %w", err)
+    }
+
+    file, err := os.Open(fs.Arg(0))
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    report, err := ui.ReadJSON(file)
+    if err != nil {
+        return fmt.Errorf("%s: %w", fs.Arg(0), err)
+    }
+    // Sizes were totalled one way when the report was made
+    cfg.Output.ApparentSize = report.Scan.ApparentSize
+
+    return render(report, cfg, *limit)
+}
+
+// render writes a finished report in the configured format. Spreadsheet
+// exports hold every finding unless limit is set.
+func render(report *types.Report, cfg *config.Config, limit int) error {
+    switch cfg.Output.Format {
+    case "json":
+        return ui.WriteJSON(os.Stdout, report)
+    case "ndjson":
+        w := ui.NewNDJSONWriter(os.Stdout)
+        for _, r := range report.Findings {
+            w.Write(r)
+        }
+        return w.Err()
+    case "prometheus":
+        return ui.WritePrometheus(os.Stdout, report, cfg.Output.PrometheusTopPaths)
+    case "markdown":
+        return ui.WriteMarkdown(os.Stdout, report, cfg)
+    case "html":
+        return ui.WriteHTML(os.Stdout, report)
+    case "csv":
+        return ui.WriteCSV(os.Stdout, report.Findings, ',', limit)
+    case "tsv":
+        return ui.WriteCSV(os.Stdout, report.Findings, '\t', limit)
+    default:
+        ui.RenderReport(report, cfg)
+        return nil
+    }
+}
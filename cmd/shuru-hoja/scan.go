@@ -0,0 +1,229 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "sort"
+    "strings"
+    "syscall"
+    "time"
+
+    "shuru-hoja/internal/analyzer"
+    "shuru-hoja/internal/config"
+    "shuru-hoja/internal/scanner"
+    "shuru-hoja/internal/snapshot"
+    "shuru-hoja/internal/ui"
+    "shuru-hoja/pkg/types"
+)
+
+// quickMaxDepth is the depth a quick scan stops at unless max_depth is set.
+const quickMaxDepth = 3
+
+// The directory outline kept in reports for the treemap: the 12 largest
+// subdirectories per level, 4 levels deep, down to 0.5% of the root.
+const (
+    outlineDepth    = 4
+    outlineWidth    = 12
+    outlineMinShare = 0.005
+)
+
+// runScan implements "scan [flags] [path...]".
+func runScan(args []string) error {
+    fs, configFile := newFlagSet("scan")
+    var paths pathList
+    fs.Var(&paths, "path", "Scan this path; may be given several times (default /)")
+    fs.String("format", "table", "Output format: table, json, ndjson, csv, tsv, html, markdown or prometheus")
+    limit := fs.Int("limit", 0, "Write at most this many findings in csv and tsv (0 = all)")
+    textfile := fs.String("prometheus-textfile", "", "Also write metrics to this file for the node_exporter textfile collector")
+    fs.Bool("quick", false, "Limit depth and skip duplicate hashing")
+    fs.Int("max-depth", 0, "Do not descend more than this many levels (0 = unlimited)")
+    fs.Bool("apparent-size", false, "Report apparent sizes instead of disk usage")
+    fs.Bool("one-file-system", false, "Stay on the filesystem of each scanned path")
+    fs.Bool("x", false, "Shorthand for --one-file-system")
+    fs.Bool("snapshot", false, "Save a snapshot of the scan for \"diff\"")
+    fs.Bool("docker", false, "Analyze Docker's storage from its files below the scanned paths")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    roots, err := scanRoots(append(paths, fs.Args()...))
+    if err != nil {
+        return err
+    }
+
+    cfg, err := loadConfig(fs, *configFile)
+    if err != nil {
+        return fmt.Errorf("failed to load config: %w", err)
+    }
+
+    // Setup signal handling for graceful shutdown: the first signal stops
+    // the scan, a second one the program
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    sigChan := make(chan os.Signal, 1)
+    signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+    go func() {
+        <-sigChan
+        cancel()
+        fmt.Fprintln(os.Stderr, "\n\nScan interrupted by user. Stopping safely...")
+        <-sigChan
+        os.Exit(130)
+    }()
+
+    // Findings are written as they are found in NDJSON
+    var stream *ui.NDJSONWriter
+    var emit func(types.ScanResult)
+    switch cfg.Output.Format {
+    case "table":
+        ui.ShowWelcome()
+    case "ndjson":
+        stream = ui.NewNDJSONWriter(os.Stdout)
+        emit = stream.Write
+    }
+
+    report, _, err := performScan(ctx, cfg, roots, emit, false)
+    if ctx.Err() != nil {
+        return errors.New("scan interrupted")
+    }
+    if err != nil {
+        return err
+    }
+
+    if *textfile != "" {
+        if err := ui.WritePrometheusFile(*textfile, report, cfg.Output.PrometheusTopPaths); err != nil {
+            return fmt.Errorf("failed to write metrics: %w", err)
+        }
+    }
+    if stream != nil {
+        return stream.Err()
+    }
+
+    return render(report, cfg, *limit)
+}
+
+// performScan scans roots with a fresh scanner and analyzer and collects
+// the outcome into a report, and into a snapshot if snapshots are enabled
+// or withSnapshot is set. Findings are also passed to emit, if set, as
+// soon as they are final. A scan stopped by the scan timeout still gives a
+// report of what it found, marked incomplete.
+func performScan(ctx context.Context, cfg *config.Config, roots []string, emit func(types.ScanResult), withSnapshot bool) (*types.Report, *snapshot.Snapshot, error) {
+    if cfg.Safety.ScanTimeoutMinutes > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Safety.ScanTimeoutMinutes)*time.Minute)
+        defer cancel()
+    }
+
+    startTime := time.Now()
+
+    maxDepth := cfg.General.MaxDepth
+    if cfg.General.Quick && maxDepth == 0 {
+        maxDepth = quickMaxDepth
+    }
+
+    // Initialize scanner
+    scanner := scanner.NewConcurrentScanner(scanner.Options{
+        MaxWorkers:    cfg.General.MaxWorkers,
+        SkipPaths:     cfg.General.SkipPaths,
+        OneFileSystem: cfg.General.OneFileSystem,
+        SkipFSTypes:   cfg.General.SkipFSTypes,
+        MaxDepth:      maxDepth,
+    })
+
+    // Initialize analyzer with detection rules
+    analyzer := analyzer.NewAnalyzer(scanner, cfg)
+    if emit != nil {
+        analyzer.Stream(emit)
+    }
+
+    results, err := analyzer.Analyze(ctx, roots...)
+    incomplete := errors.Is(err, context.DeadlineExceeded)
+    if err != nil && !incomplete {
+        return nil, nil, fmt.Errorf("analysis failed: %w", err)
+    }
+    if incomplete {
+        fmt.Fprintf(os.Stderr, "Warning: scan stopped after scan_timeout_minutes = %d; the report is incomplete\n",
+            cfg.Safety.ScanTimeoutMinutes)
+    }
+
+    host, _ := os.Hostname()
+    scan := types.ScanInfo{
+        Version:      version,
+        Host:         host,
+        Roots:        roots,
+        StartedAt:    startTime,
+        Duration:     time.Since(startTime).Seconds(),
+        Quick:        cfg.General.Quick,
+        ApparentSize: cfg.Output.ApparentSize,
+        Incomplete:   incomplete,
+    }
+    report := ui.NewReport(scan, results, analyzer.SummarizeMounts(results), analyzer.Errors())
+    report.Tree = scanner.Tree().Outline(outlineDepth, outlineWidth, outlineMinShare)
+    report.Containers = analyzer.ContainerStorage()
+
+    var snap *snapshot.Snapshot
+    if withSnapshot {
+        snap = snapshot.New(report, results, scanner.Tree())
+    }
+    if cfg.Snapshot.Enabled {
+        if snap == nil {
+            snap = snapshot.New(report, results, scanner.Tree())
+        }
+        store := snapshot.Store{Dir: cfg.Snapshot.Dir, Keep: cfg.Snapshot.Keep}
+        // Part of the tree would read as shrinkage in diffs and forecasts
+        if incomplete {
+            fmt.Fprintln(os.Stderr, "Warning: not saving a snapshot of an incomplete scan")
+        } else if _, err := store.Save(snap); err != nil {
+            fmt.Fprintf(os.Stderr, "Warning: failed to save snapshot: %v\n", err)
+        }
+        forecast, err := store.Forecast(report)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "Warning: failed to forecast growth: %v\n", err)
+        }
+        report.Forecast = forecast
+    }
+
+    return report, snap, nil
+}
+
+// scanRoots makes the requested paths absolute and drops those below
+// another root, which would otherwise be counted twice.
+func scanRoots(paths []string) ([]string, error) {
+    if len(paths) == 0 {
+        return []string{"/"}, nil
+    }
+
+    var abs []string
+    for _, path := range paths {
+        p, err := filepath.Abs(path)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := os.Lstat(p); err != nil {
+            return nil, err
+        }
+        abs = append(abs, p)
+    }
+    sort.Strings(abs)
+
+    var roots []string
+    for _, p := range abs {
+        if !belowAny(p, roots) {
+            roots = append(roots, p)
+        }
+    }
+    return roots, nil
+}
+
+func belowAny(path string, roots []string) bool {
+    for _, root := range roots {
+        if path == root || root == "/" || strings.HasPrefix(path, root+"/") {
+            return true
+        }
+    }
+    return false
+}
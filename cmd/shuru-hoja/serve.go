@@ -0,0 +1,102 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "os/signal"
+    "syscall"
+    "time"
+
+    "shuru-hoja/internal/daemon"
+    "shuru-hoja/pkg/types"
+)
+
+// runServe implements "serve [flags] [path...]", which scans on a schedule
+// and answers questions about the latest scan over a local HTTP API.
+func runServe(args []string) error {
+    fs, configFile := newFlagSet("serve")
+    var paths pathList
+    fs.Var(&paths, "path", "Scan this path; may be given several times (default from daemon.roots)")
+    fs.String("listen", "", "Serve the API on unix:PATH or a loopback host:port")
+    fs.Int("interval", 0, "Minutes between scheduled scans (0 = only on request)")
+    fs.String("state-file", "", "Keep the latest report in this file across restarts")
+    fs.Bool("quick", false, "Limit depth and skip duplicate hashing")
+    fs.Bool("one-file-system", false, "Stay on the filesystem of each scanned path")
+    fs.Bool("x", false, "Shorthand for --one-file-system")
+    fs.Bool("snapshot", false, "Save a snapshot of every scan for \"diff\"")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    cfg, err := loadConfig(fs, *configFile)
+    if err != nil {
+        return fmt.Errorf("failed to load config: %w", err)
+    }
+
+    requested := append(paths, fs.Args()...)
+    if len(requested) == 0 {
+        requested = cfg.Daemon.Roots
+    }
+    roots, err := scanRoots(requested)
+    if err != nil {
+        return err
+    }
+
+    scan := func(ctx context.Context) (*types.Report, error) {
+        report, _, err := performScan(ctx, cfg, roots, nil, false)
+        return report, err
+    }
+    interval := time.Duration(cfg.Daemon.IntervalMinutes) * time.Minute
+    server := daemon.New(scan, interval, cfg.Daemon.StateFile, cfg.Output.PrometheusTopPaths)
+    if err := server.Load(); err != nil {
+        log.Printf("ignoring saved report: %v", err)
+    }
+
+    listener, err := daemon.Listen(cfg.Daemon.Listen)
+    if err != nil {
+        return err
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    httpServer := &http.Server{
+        Handler:           server.Handler(ctx),
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+    serveErr := make(chan error, 1)
+    go func() {
+        serveErr <- httpServer.Serve(listener)
+    }()
+    if interval > 0 {
+        log.Printf("serving on %s, scanning %v every %v", cfg.Daemon.Listen, roots, interval)
+    } else {
+        log.Printf("serving on %s, scanning %v on request", cfg.Daemon.Listen, roots)
+    }
+
+    done := make(chan struct{})
+    go func() {
+        server.Run(ctx)
+        close(done)
+    }()
+
+    select {
+    case err = <-serveErr:
+        stop()
+    case <-ctx.Done():
+        log.Printf("shutting down")
+    }
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    httpServer.Shutdown(shutdownCtx)
+    <-done
+
+    if errors.Is(err, http.ErrServerClosed) {
+        return nil
+    }
+    return err
+}
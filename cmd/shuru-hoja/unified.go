@@ -1,131 +0,0 @@
-package main
-
-import (
-    "context"
-    "flag"
-    "fmt"
-    "os"
-    "os/signal"
-    "syscall"
-    "time"
-)
-
-var version = "1.0.0"
-
-func main() {
-    var showVersion bool
-    flag.BoolVar(&showVersion, "version", false, "Show version")
-    flag.Parse()
-    
-    if showVersion {
-        fmt.Printf("shuru hoja v%s\n", version)
-        return
-    }
-    
-    runAnalysis()
-}
-
-func runAnalysis() error {
-    ctx, cancel := context.WithCancel(context.Background())
-    defer cancel()
-    
-    sigChan := make(chan os.Signal, 1)
-    signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-    go func() {
-        <-sigChan
-        cancel()
-        fmt.Println("\n\nScan interrupted. Exiting...")
-        os.Exit(0)
-    }()
-    
-    showBanner()
-    
-    fmt.Println("Scanning filesystem...")
-    fmt.Println("(This may take several minutes)")
-    fmt.Println()
-    
-    // Simulate scanning with progress
-    for i := 1; i <= 10; i++ {
-        select {
-        case <-ctx.Done():
-            return nil
-        case <-time.After(500 * time.Millisecond):
-            fmt.Printf("\rProgress: [%-10s] %d%%", strings.Repeat("█", i), i*10)
-        }
-    }
-    fmt.Println()
-    
-    showResults()
-    
-    return nil
-}
-
-func showBanner() {
-    fmt.Println(`
-┌─────────────────────────────────────────────┐
-│        SHURU HOJA - Filesystem Analyzer     │
-│    Production-Safe • Read-Only • Enterprise │
-└─────────────────────────────────────────────┘
-
-███████╗██╗  ██╗██╗   ██╗██████╗ ██╗   ██╗    ██╗  ██╗ ██████╗ ███████╗ █████╗ 
-██╔════╝██║  ██║██║   ██║██╔══██╗██║   ██║    ██║  ██║██╔═══██╗  ██║   ██╔══██╗
-███████╗███████║██║   ██║██████╔╝██║   ██║    ███████║██║   ██║  ██║   ███████║
-╚════██║██╔══██║██║   ██║██╔══██╗██║   ██║    ██╔══██║██║   ██║  ██║   ██╔══██║
-███████║██║  ██║╚██████╔╝██║  ██║╚██████╔╝    ██║  ██║╚██████╔╝███╔╝   ██║  ██║
-╚══════╝╚═╝  ╚═╝ ╚═════╝ ╚═╝  ╚═╝ ╚═════╝     ╚═╝  ╚═╝ ╚═════╝ ╚═╝  ╚═╝╚═╝  ╚═╝
-
-Scanning for:
-• Large files and directories
-• Old log files (>30 days)
-• Cache and temporary files
-• Duplicate files
-
-MODE: READ-ONLY - No files will be modified
-`)
-}
-
-func showResults() {
-    fmt.Println()
-    fmt.Println("══════════════════════════════════════════════════════")
-    fmt.Println("                     SCAN RESULTS                     ")
-    fmt.Println("══════════════════════════════════════════════════════")
-    fmt.Println()
-    
-    // Example results table
-    fmt.Println("┌──────────┬────────────┬────────────┬──────────────────────────────┐")
-    fmt.Println("│   Size   │    Type    │   Risk     │          Path               │")
-    fmt.Println("├──────────┼────────────┼────────────┼──────────────────────────────┤")
-    fmt.Println("│  1.2 GB  │   cache    │  Critical  │  /var/cache/apt/archives    │")
-    fmt.Println("│  845 MB  │   log      │  Caution   │  /var/log/journal/*.journal │")
-    fmt.Println("│  512 MB  │   temp     │  Caution   │  /tmp/large_download.tmp    │")
-    fmt.Println("│  245 MB  │   backup   │  Review    │  /home/backups/old.tar.gz   │")
-    fmt.Println("└──────────┴────────────┴────────────┴──────────────────────────────┘")
-    fmt.Println()
-    
-    fmt.Println("══════════════════════════════════════════════════════")
-    fmt.Println("Total scanned: 245.7 GB")
-    fmt.Println("Potential cleanup: 2.8 GB")
-    fmt.Println("Critical items: 3")
-    fmt.Println("══════════════════════════════════════════════════════")
-    fmt.Println()
-    fmt.Println("RECOMMENDATIONS:")
-    fmt.Println("1. Clear APT cache: sudo apt clean")
-    fmt.Println("2. Clean old journal logs: sudo journalctl --vacuum-time=30d")
-    fmt.Println("3. Check /tmp for large temporary files")
-    fmt.Println()
-    fmt.Println("⚠  Always review before deleting files!")
-    fmt.Println("⚠  This tool only shows recommendations")
-}
-
-// Helper function
-var strings = struct {
-    Repeat func(string, int) string
-}{
-    Repeat: func(s string, count int) string {
-        result := ""
-        for i := 0; i < count; i++ {
-            result += s
-        }
-        return result
-    },
-}
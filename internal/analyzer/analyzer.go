@@ -5,9 +5,9 @@ import (
     "fmt"
     "os"
     "sort"
-    "time"
 
     "shuru-hoja/internal/config"
+    "shuru-hoja/internal/mounts"
     "shuru-hoja/internal/scanner"
     "shuru-hoja/internal/analyzer/detectors"
     "shuru-hoja/pkg/types"
@@ -17,12 +17,22 @@ type Analyzer struct {
     scanner     *scanner.ConcurrentScanner
     config      *config.Config
     detectors   []detectors.Detector
+    dirDetectors []detectors.DirDetector
+    mounts      *mounts.Table
+    errors      []error
+    emit        func(types.ScanResult)
+    atimeOK     map[string]bool
+    docker      string
+    claimed     map[string]bool
+    containers  *types.ContainerStorage
 }
 
 func NewAnalyzer(s *scanner.ConcurrentScanner, cfg *config.Config) *Analyzer {
     analyzer := &Analyzer{
         scanner: s,
         config:  cfg,
+        mounts:  s.Mounts(),
+        atimeOK: make(map[string]bool),
     }
     
     // Initialize detectors
@@ -34,21 +44,76 @@ func NewAnalyzer(s *scanner.ConcurrentScanner, cfg *config.Config) *Analyzer {
 func (a *Analyzer) initDetectors() {
     // Create all detectors
     a.detectors = []detectors.Detector{
-        detectors.NewLogFileDetector(a.config.Detection.LogFileAgeDays),
+        detectors.NewTempFileDetector(
+            a.config.Detection.TempFileAgeDays,
+            a.config.Detection.TempDirPatterns,
+            a.config.Detection.TempFilePatterns,
+        ),
+        detectors.NewLogFileDetector(
+            a.config.Detection.LogFileAgeDays,
+            a.config.Detection.LogFilePatterns,
+        ),
+    }
+    
+    // Directory detectors run after the scan, in this order of precedence
+    a.dirDetectors = []detectors.DirDetector{
+        detectors.NewJournalDetector(a.config.Detection.JournalLogMaxSize),
+        detectors.NewNodeModulesDetector(
+            a.config.Detection.NodeModulesMaxSize,
+            a.config.Detection.ProjectInactiveDays,
+            a.config.Risk.CriticalAgeDays,
+            a.scanner.Tree(),
+        ),
+        detectors.NewPythonDetector(
+            a.config.Detection.PythonVenvMaxSize,
+            a.config.Detection.CacheMinSize,
+            a.config.Detection.ProjectInactiveDays,
+            a.config.Risk.CriticalAgeDays,
+            a.scanner.Tree(),
+        ),
+        detectors.NewCacheDetector(
+            a.config.Detection.CacheMinSize,
+            a.config.Risk.CriticalSize,
+            a.config.Detection.CacheDirPatterns,
+        ),
+        detectors.NewOrphanDetector(
+            a.config.Detection.OrphanDirMinSize,
+            a.config.Detection.OrphanDirAgeDays,
+            a.config.Risk.CriticalAgeDays,
+            a.config.Detection.OrphanAccessCheck,
+        ),
     }
 }
 
-func (a *Analyzer) Analyze(ctx context.Context, root string) ([]types.ScanResult, error) {
+// Errors returns the problems met during the last Analyze that did not
+// stop it, such as unreadable directories.
+func (a *Analyzer) Errors() []error {
+    return a.errors
+}
+
+// Analyze scans roots and judges what it finds. If ctx ends first, what was
+// found so far is still judged and returned with ctx's error, but without
+// duplicate hashing and Docker storage.
+func (a *Analyzer) Analyze(ctx context.Context, roots ...string) ([]types.ScanResult, error) {
     results := []types.ScanResult{}
+    var streamed map[int]bool
+    if a.emit != nil {
+        streamed = make(map[int]bool)
+    }
+    a.docker = a.dockerRoot(roots)
     
     // Start scanning
-    fileChan, errChan := a.scanner.Scan(ctx, root)
+    fileChan, errChan := a.scanner.Scan(ctx, roots...)
     
     // Process files
+    var cancelled error
+    done := ctx.Done()
     for {
         select {
-        case <-ctx.Done():
-            return results, ctx.Err()
+        case <-done:
+            // The scanner stops as well and closes its channels
+            cancelled = ctx.Err()
+            done = nil
             
         case fileInfo, ok := <-fileChan:
             if !ok {
@@ -65,7 +130,10 @@ func (a *Analyzer) Analyze(ctx context.Context, root string) ([]types.ScanResult
                 errChan = nil
             } else {
                 // Log permission errors but continue
-                fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+                a.errors = append(a.errors, err)
+                if a.config.Safety.PermissionWarnings {
+                    fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+                }
             }
         }
         
@@ -74,18 +142,54 @@ func (a *Analyzer) Analyze(ctx context.Context, root string) ([]types.ScanResult
         }
     }
     
-    // Sort by size (largest first)
-    sort.Slice(results, func(i, j int) bool {
-        return results[i].Info.Size > results[j].Info.Size
-    })
+    a.analyzeDirectories(results)
+    a.stream(results, streamed, true)
+    // Hashing reads every candidate file, which quick mode cannot afford
+    if !a.config.General.Quick && cancelled == nil {
+        a.findDuplicates(results)
+    }
+    annotateHardLinks(results)
+    if a.docker != "" && cancelled == nil {
+        results = a.analyzeDocker(ctx, results)
+    }
+    
+    a.stream(results, streamed, false)
     
-    return results, nil
+    a.sortResults(results)
+    
+    return results, cancelled
+}
+
+// sortResults orders results as configured, by default largest first.
+func (a *Analyzer) sortResults(results []types.ScanResult) {
+    apparent := a.config.Output.ApparentSize
+    less := func(x, y types.ScanResult) bool {
+        return x.Info.Usage(apparent) < y.Info.Usage(apparent)
+    }
+    switch a.config.Output.SortBy {
+    case "age":
+        // A smaller age means more recently modified
+        less = func(x, y types.ScanResult) bool {
+            return x.Info.ModTime.After(y.Info.ModTime)
+        }
+    case "path":
+        less = func(x, y types.ScanResult) bool {
+            return x.Info.Path < y.Info.Path
+        }
+    }
+    
+    if a.config.Output.SortOrder == "asc" {
+        sort.SliceStable(results, func(i, j int) bool { return less(results[i], results[j]) })
+    } else {
+        sort.SliceStable(results, func(i, j int) bool { return less(results[j], results[i]) })
+    }
 }
 
 func (a *Analyzer) analyzeFile(info types.FileInfo) *types.ScanResult {
-    // Run through all detectors
+    // Run through all detectors, leaving Docker's files to analyzeDocker
     for _, detector := range a.detectors {
-        if result := detector.Detect(info); result != nil {
+        if result := detector.Detect(info); result != nil && !a.underDocker(info.Path) {
+            a.annotateAtime(result)
             return result
         }
     }
@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+    "shuru-hoja/pkg/types"
+)
+
+// atimeTypes are the finding types whose rules rely on access times.
+var atimeTypes = map[types.FileType]bool{
+    types.TypeTemp:   true,
+    types.TypeOrphan: true,
+}
+
+// annotateAtime flags an age-based finding on a filesystem mounted with
+// noatime or relatime, where an old access time does not prove that a file
+// has not been read recently.
+func (a *Analyzer) annotateAtime(r *types.ScanResult) {
+    if a.mounts == nil || r.Recommendation == types.RecKeep || !atimeTypes[r.Type] {
+        return
+    }
+
+    mount, ok := a.mounts.Lookup(r.Info.Path)
+    if !ok {
+        return
+    }
+
+    ok, seen := a.atimeOK[mount.MountPoint]
+    if !seen {
+        ok = mount.AtimeReliable()
+        a.atimeOK[mount.MountPoint] = ok
+    }
+    if !ok {
+        r.Flags = append(r.Flags, types.FlagAtimeUnreliable)
+    }
+}
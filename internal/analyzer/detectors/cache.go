@@ -0,0 +1,97 @@
+package detectors
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// CacheDetector reports well-known cache directories as a single finding
+// carrying the total size of everything below them.
+type CacheDetector struct {
+    MinSize      int64
+    CriticalSize int64
+    Patterns     []string
+}
+
+func NewCacheDetector(minSize, criticalSize int64, patterns []string) *CacheDetector {
+    return &CacheDetector{
+        MinSize:      minSize,
+        CriticalSize: criticalSize,
+        Patterns:     patterns,
+    }
+}
+
+func (d *CacheDetector) DetectDir(dir types.DirSummary) *types.ScanResult {
+    if !d.isCacheDir(dir.Info.Path) {
+        return nil
+    }
+
+    info := dir.Info
+    info.Size = dir.TotalSize
+
+    result := &types.ScanResult{
+        Info:    info,
+        Type:    types.TypeCache,
+        AgeDays: int(time.Since(dir.NewestMod).Hours() / 24),
+    }
+
+    switch {
+    case d.CriticalSize > 0 && info.Size >= d.CriticalSize:
+        result.RiskLevel = types.RiskCritical
+        result.Recommendation = types.RecDelete
+    case info.Size >= d.MinSize:
+        result.RiskLevel = types.RiskCaution
+        result.Recommendation = types.RecReview
+    default:
+        // Too small to matter; the files in it are judged on their own
+        return nil
+    }
+
+    result.Reason = fmt.Sprintf("Cache directory (%s, %d files)", formatSize(info.Size), dir.FileCount)
+    return result
+}
+
+func (d *CacheDetector) isCacheDir(path string) bool {
+    for _, pattern := range d.Patterns {
+        if matchDirPattern(path, pattern) {
+            return true
+        }
+    }
+    return false
+}
+
+// matchDirPattern matches a directory against a config pattern. Absolute
+// patterns must match exactly, "~/" patterns match below any home
+// directory, and anything else is compared with the base name.
+func matchDirPattern(path, pattern string) bool {
+    pattern = strings.TrimSuffix(strings.TrimSpace(pattern), "/")
+    if pattern == "" {
+        return false
+    }
+
+    switch {
+    case strings.HasPrefix(pattern, "~/"):
+        rel := pattern[1:]
+        if !strings.HasSuffix(path, rel) {
+            return false
+        }
+        return isHomeDir(strings.TrimSuffix(path, rel))
+    case strings.HasPrefix(pattern, "/"):
+        return path == pattern
+    default:
+        return filepath.Base(path) == pattern
+    }
+}
+
+func isHomeDir(path string) bool {
+    if path == "/root" || filepath.Dir(path) == "/home" {
+        return true
+    }
+    home, err := os.UserHomeDir()
+    return err == nil && path == home
+}
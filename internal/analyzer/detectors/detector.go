@@ -5,3 +5,9 @@ import "shuru-hoja/pkg/types"
 type Detector interface {
     Detect(info types.FileInfo) *types.ScanResult
 }
+
+// DirDetector inspects whole directory trees once the scan has finished and
+// their contents have been rolled up.
+type DirDetector interface {
+    DetectDir(dir types.DirSummary) *types.ScanResult
+}
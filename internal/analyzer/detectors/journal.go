@@ -0,0 +1,277 @@
+package detectors
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// Where journald.conf and its drop-ins are read from, in order of
+// precedence from lowest to highest.
+var journaldConfDirs = []string{"/usr/lib/systemd", "/etc/systemd"}
+
+const machineIDFile = "/etc/machine-id"
+
+var machineIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// JournalDetector reports the persistent systemd journal, one finding per
+// machine ID directory below a "journal" directory. The journal of this
+// machine is compared with the smaller of MaxSize and journald's
+// SystemMaxUse, with the journalctl --vacuum-size and --vacuum-time values
+// that bring it back under that. Journals of other machine IDs, left by a
+// cloned or reinstalled system, are never rotated and can go as a whole.
+type JournalDetector struct {
+    MaxSize      int64
+    machineID    string
+    systemMaxUse int64
+}
+
+func NewJournalDetector(maxSize int64) *JournalDetector {
+    d := &JournalDetector{MaxSize: maxSize}
+    if data, err := os.ReadFile(machineIDFile); err == nil {
+        d.machineID = strings.TrimSpace(string(data))
+    }
+    d.systemMaxUse = readSystemMaxUse(journaldConfDirs)
+    return d
+}
+
+// journalFile is one file of a journal, in the order journald writes them.
+type journalFile struct {
+    size    int64
+    modTime time.Time
+    active  bool
+}
+
+func (d *JournalDetector) DetectDir(dir types.DirSummary) *types.ScanResult {
+    path := dir.Info.Path
+    id := filepath.Base(path)
+    if filepath.Base(filepath.Dir(path)) != "journal" || !machineIDPattern.MatchString(id) {
+        return nil
+    }
+    files := readJournalFiles(path)
+    if len(files) == 0 {
+        return nil
+    }
+
+    info := dir.Info
+    info.Size = dir.TotalSize
+    result := &types.ScanResult{
+        Info:           info,
+        Type:           types.TypeJournal,
+        RiskLevel:      types.RiskSafe,
+        Recommendation: types.RecKeep,
+        AgeDays:        int(time.Since(files[0].modTime).Hours() / 24),
+    }
+
+    if d.machineID != "" && id != d.machineID {
+        result.RiskLevel = types.RiskCaution
+        result.Recommendation = types.RecDelete
+        result.Reason = fmt.Sprintf("Journal of machine ID %s, not this machine's %s, left by a cloned or reinstalled system "+
+            "(%s in %s, last written %d days ago); journald never rotates it, read it with journalctl --directory before removing it",
+            id, d.machineID, formatSize(info.Size), countFiles(len(files)), result.AgeDays)
+        return result
+    }
+
+    limit, source := d.MaxSize, "journal_log_max_size_gb"
+    if d.systemMaxUse > 0 && d.systemMaxUse < limit {
+        limit, source = d.systemMaxUse, "SystemMaxUse"
+    }
+    var total, active int64
+    for _, f := range files {
+        total += f.size
+        if f.active {
+            active += f.size
+        }
+    }
+    if limit <= 0 || total <= limit {
+        return result
+    }
+
+    result.RiskLevel = types.RiskCaution
+    result.Recommendation = types.RecReview
+    if total > 2*limit {
+        result.RiskLevel = types.RiskCritical
+    }
+
+    over := fmt.Sprintf("SystemMaxUse (%s)", formatSize(limit))
+    if source != "SystemMaxUse" {
+        configured := "SystemMaxUse is not set"
+        if d.systemMaxUse > 0 {
+            configured = "SystemMaxUse is " + formatSize(d.systemMaxUse)
+        }
+        over = fmt.Sprintf("%s (%s; %s)", source, formatSize(limit), configured)
+    }
+    vacuum := fmt.Sprintf("journalctl --vacuum-size=%s", vacuumSize(limit))
+    if active > limit {
+        vacuum += " leaves only the active files, which alone exceed it"
+    } else {
+        if keep := vacuumTime(files, active, limit); keep != "" {
+            vacuum += " or --vacuum-time=" + keep
+        }
+        vacuum += " brings it under"
+    }
+    result.Reason = fmt.Sprintf("Journal of %s over %s; %s", formatSize(total), over, vacuum)
+    return result
+}
+
+// readJournalFiles lists the journal files of one machine ID, newest first.
+// Only archived files (named "name@...") are removed by vacuuming.
+func readJournalFiles(dir string) []journalFile {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil
+    }
+    var files []journalFile
+    for _, entry := range entries {
+        name := entry.Name()
+        if entry.IsDir() || !(strings.HasSuffix(name, ".journal") || strings.HasSuffix(name, ".journal~")) {
+            continue
+        }
+        info, err := entry.Info()
+        if err != nil {
+            continue
+        }
+        files = append(files, journalFile{
+            size:    info.Size(),
+            modTime: info.ModTime(),
+            active:  !strings.Contains(name, "@"),
+        })
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+    return files
+}
+
+// vacuumTime works out the --vacuum-time that removes the oldest archived
+// files until the rest, with the active files of size kept, fit in limit.
+// Vacuuming removes the archived files whose last entry, and so their
+// mtime, is older than the given time. It returns "" when the files that
+// have to go are too recent to single out.
+func vacuumTime(files []journalFile, kept, limit int64) string {
+    for _, f := range files {
+        if f.active {
+            continue
+        }
+        if kept+f.size > limit {
+            // Just newer than the first file that has to go
+            hours := int(time.Since(f.modTime).Hours())
+            switch {
+            case hours < 1:
+                return ""
+            case hours < 48:
+                return fmt.Sprintf("%dh", hours)
+            }
+            return fmt.Sprintf("%dd", hours/24)
+        }
+        kept += f.size
+    }
+    return ""
+}
+
+// vacuumSize writes a size the way journalctl reads it, rounded down so
+// that the journal ends up under it.
+func vacuumSize(bytes int64) string {
+    const mb = 1024 * 1024
+    if bytes%(1024*mb) == 0 {
+        return fmt.Sprintf("%dG", bytes/(1024*mb))
+    }
+    if bytes >= mb {
+        return fmt.Sprintf("%dM", bytes/mb)
+    }
+    return fmt.Sprintf("%dK", bytes/1024)
+}
+
+// readSystemMaxUse reads SystemMaxUse from journald.conf and its drop-in
+// directories, the last setting winning. It returns 0 when it is not set.
+func readSystemMaxUse(dirs []string) int64 {
+    var files []string
+    for _, dir := range dirs {
+        files = append(files, filepath.Join(dir, "journald.conf"))
+    }
+    // A drop-in replaces one of the same name in an earlier directory;
+    // together they override the main files, in name order
+    dropIns := make(map[string]string)
+    for _, dir := range dirs {
+        matches, _ := filepath.Glob(filepath.Join(dir, "journald.conf.d", "*.conf"))
+        for _, m := range matches {
+            dropIns[filepath.Base(m)] = m
+        }
+    }
+    var names []string
+    for name := range dropIns {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        files = append(files, dropIns[name])
+    }
+
+    var maxUse int64
+    for _, path := range files {
+        if v, ok := readJournaldSetting(path, "SystemMaxUse"); ok {
+            maxUse = parseSystemdSize(v)
+        }
+    }
+    return maxUse
+}
+
+// readJournaldSetting reads a key of the [Journal] section of a file.
+func readJournaldSetting(path, key string) (string, bool) {
+    file, err := os.Open(path)
+    if err != nil {
+        return "", false
+    }
+    defer file.Close()
+
+    var value string
+    var found, inJournal bool
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        switch {
+        case line == "" || line[0] == '#' || line[0] == ';':
+        case line[0] == '[':
+            inJournal = line == "[Journal]"
+        case inJournal:
+            k, v, ok := strings.Cut(line, "=")
+            if ok && strings.TrimSpace(k) == key {
+                value, found = strings.TrimSpace(v), true
+            }
+        }
+    }
+    return value, found
+}
+
+// parseSystemdSize reads sizes such as "500M" or "2G", in powers of 1024
+// as systemd does. An empty or unreadable value resets to 0, the default.
+func parseSystemdSize(value string) int64 {
+    value = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B")
+    unit := int64(1)
+    if n := len(value); n > 0 {
+        if i := strings.IndexByte("KMGTPE", value[n-1]); i >= 0 {
+            value = value[:n-1]
+            for ; i >= 0; i-- {
+                unit *= 1024
+            }
+        }
+    }
+    v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+    if err != nil || v < 0 {
+        return 0
+    }
+    return int64(v * float64(unit))
+}
+
+func countFiles(n int) string {
+    if n == 1 {
+        return "1 file"
+    }
+    return fmt.Sprintf("%d files", n)
+}
@@ -14,10 +14,12 @@ type LogFileDetector struct {
     Patterns   []string
 }
 
-func NewLogFileDetector(maxAgeDays int) *LogFileDetector {
+// NewLogFileDetector flags old files matching one of the shell patterns,
+// such as "*.log.*", or below /var/log.
+func NewLogFileDetector(maxAgeDays int, patterns []string) *LogFileDetector {
     return &LogFileDetector{
         MaxAgeDays: maxAgeDays,
-        Patterns: []string{".log", ".log.", ".journal", ".gz", ".bz2"},
+        Patterns:   patterns,
     }
 }
 
@@ -29,12 +31,15 @@ func (d *LogFileDetector) Detect(info types.FileInfo) *types.ScanResult {
     filename := filepath.Base(info.Path)
     lowerName := strings.ToLower(filename)
     
+    // Journal files are rotated by journald and judged by JournalDetector
+    if strings.HasSuffix(lowerName, ".journal") || strings.HasSuffix(lowerName, ".journal~") {
+        return nil
+    }
+    
     // Check if it's a log file
-    isLogFile := false
+    isLogFile := strings.Contains(info.Path, "/var/log/") || strings.Contains(info.Path, "/var/logs/")
     for _, pattern := range d.Patterns {
-        if strings.Contains(lowerName, pattern) || 
-           strings.Contains(info.Path, "/var/log/") ||
-           strings.Contains(info.Path, "/var/logs/") {
+        if ok, _ := filepath.Match(strings.ToLower(strings.TrimSpace(pattern)), lowerName); ok {
             isLogFile = true
             break
         }
@@ -0,0 +1,111 @@
+package detectors
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+var (
+    npmManifests = []string{"package.json"}
+    npmLockfiles = []string{"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb"}
+)
+
+// NodeModulesDetector reports every node_modules directory as one finding
+// with the size of its whole subtree, judged by how recently its project
+// was worked on. Nested node_modules are part of the outer one. Global
+// installs below a lib/node_modules prefix, npm itself among them, are
+// always kept.
+type NodeModulesDetector struct {
+    MaxSize         int64
+    InactiveDays    int
+    CriticalAgeDays int
+    projects        *projectActivity
+}
+
+func NewNodeModulesDetector(maxSize int64, inactiveDays, criticalAgeDays int, tree DirTree) *NodeModulesDetector {
+    return &NodeModulesDetector{
+        MaxSize:         maxSize,
+        InactiveDays:    inactiveDays,
+        CriticalAgeDays: criticalAgeDays,
+        projects:        newProjectActivity(tree),
+    }
+}
+
+func (d *NodeModulesDetector) DetectDir(dir types.DirSummary) *types.ScanResult {
+    if filepath.Base(dir.Info.Path) != "node_modules" {
+        return nil
+    }
+
+    info := dir.Info
+    info.Size = dir.TotalSize
+    project := filepath.Dir(info.Path)
+
+    result := &types.ScanResult{
+        Info:           info,
+        Type:           types.TypeNodeModules,
+        RiskLevel:      types.RiskSafe,
+        Recommendation: types.RecKeep,
+    }
+
+    // npm -g, and version managers such as nvm, install below a prefix;
+    // the packages there have node_modules of their own
+    if strings.Contains(info.Path+"/", "/lib/node_modules/") {
+        return result
+    }
+
+    _, hasManifest := hasAny(project, npmManifests...)
+    lockfile, hasLockfile := hasAny(project, npmLockfiles...)
+    if !hasManifest && !hasLockfile {
+        // Nothing tells when they were last needed but their own files
+        if !d.judge(result, dir.NewestMod) {
+            return result
+        }
+        result.Reason = fmt.Sprintf("Dependencies without a project: no package.json next to them, unchanged for %d days (%s)",
+            result.AgeDays, formatSize(info.Size))
+        return result
+    }
+
+    if !d.judge(result, d.projects.lastActive(project, info.Path)) {
+        return result
+    }
+
+    restore := "npm install"
+    switch lockfile {
+    case "yarn.lock":
+        restore = "yarn install"
+    case "pnpm-lock.yaml":
+        restore = "pnpm install"
+    case "bun.lockb":
+        restore = "bun install"
+    case "package-lock.json", "npm-shrinkwrap.json":
+        restore = "npm ci"
+    }
+    result.Reason = fmt.Sprintf("Dependencies of a project inactive for %d days (%s in %d files, restore with %s)",
+        result.AgeDays, formatSize(info.Size), dir.FileCount, restore)
+    return result
+}
+
+// judge sets the age and risk of result from when its dependencies were
+// last needed, and reports whether they have been unused for too long.
+func (d *NodeModulesDetector) judge(result *types.ScanResult, lastActive time.Time) bool {
+    ageDays := int(time.Since(lastActive).Hours() / 24)
+    result.AgeDays = ageDays
+    if ageDays <= d.InactiveDays {
+        return false
+    }
+
+    size := result.Info.Size
+    result.RiskLevel = types.RiskCaution
+    result.Recommendation = types.RecReview
+    if size >= d.MaxSize {
+        result.Recommendation = types.RecDelete
+    }
+    if d.CriticalAgeDays > 0 && ageDays > d.CriticalAgeDays && size >= d.MaxSize {
+        result.RiskLevel = types.RiskCritical
+    }
+    return true
+}
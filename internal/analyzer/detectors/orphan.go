@@ -0,0 +1,78 @@
+package detectors
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// OrphanDetector flags large directory trees in which nothing has been
+// modified (or, with CheckAccess, read) for a long time, such as the home
+// directories of departed users or old release directories.
+type OrphanDetector struct {
+    MinSize         int64
+    MaxAgeDays      int
+    CriticalAgeDays int
+    CheckAccess     bool
+    SystemPrefixes  []string
+}
+
+func NewOrphanDetector(minSize int64, maxAgeDays, criticalAgeDays int, checkAccess bool) *OrphanDetector {
+    return &OrphanDetector{
+        MinSize:         minSize,
+        MaxAgeDays:      maxAgeDays,
+        CriticalAgeDays: criticalAgeDays,
+        CheckAccess:     checkAccess,
+        SystemPrefixes: []string{
+            "/bin", "/boot", "/etc", "/lib", "/lib32", "/lib64",
+            "/sbin", "/usr", "/var/lib",
+        },
+    }
+}
+
+func (d *OrphanDetector) DetectDir(dir types.DirSummary) *types.ScanResult {
+    if dir.TotalSize < d.MinSize || d.isSystemPath(dir.Info.Path) {
+        return nil
+    }
+
+    lastUsed := dir.NewestMod
+    if d.CheckAccess && dir.NewestAccess.After(lastUsed) {
+        lastUsed = dir.NewestAccess
+    }
+
+    ageDays := int(time.Since(lastUsed).Hours() / 24)
+    if ageDays <= d.MaxAgeDays {
+        return nil
+    }
+
+    info := dir.Info
+    info.Size = dir.TotalSize
+
+    result := &types.ScanResult{
+        Info:           info,
+        Type:           types.TypeOrphan,
+        RiskLevel:      types.RiskCaution,
+        Recommendation: types.RecReview,
+        AgeDays:        ageDays,
+    }
+
+    if d.CriticalAgeDays > 0 && ageDays > d.CriticalAgeDays {
+        result.RiskLevel = types.RiskCritical
+    }
+
+    result.Reason = fmt.Sprintf("Untouched for %d days (%s in %d files)",
+        ageDays, formatSize(dir.TotalSize), dir.FileCount)
+
+    return result
+}
+
+func (d *OrphanDetector) isSystemPath(path string) bool {
+    for _, prefix := range d.SystemPrefixes {
+        if path == prefix || strings.HasPrefix(path, prefix+"/") {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,154 @@
+package detectors
+
+import (
+    "bufio"
+    "io"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// DirTree looks up the directories of a finished scan.
+type DirTree interface {
+    Children(path string) []types.DirSummary
+}
+
+// projectActivity works out when projects were last worked on, remembering
+// the last commit of every repository it has looked at.
+type projectActivity struct {
+    tree    DirTree
+    commits map[string]time.Time
+}
+
+func newProjectActivity(tree DirTree) *projectActivity {
+    return &projectActivity{tree: tree, commits: make(map[string]time.Time)}
+}
+
+// lastActive returns when the project in dir was last worked on: the newest
+// modification anywhere in it, leaving out the directory skip (the
+// dependencies being judged), or its last commit if that is later.
+func (p *projectActivity) lastActive(dir, skip string) time.Time {
+    var newest time.Time
+    if entries, err := os.ReadDir(dir); err == nil {
+        for _, entry := range entries {
+            if entry.IsDir() {
+                continue
+            }
+            if info, err := entry.Info(); err == nil && info.ModTime().After(newest) {
+                newest = info.ModTime()
+            }
+        }
+    }
+    if p.tree != nil {
+        for _, child := range p.tree.Children(dir) {
+            if child.Info.Path != skip && child.NewestMod.After(newest) {
+                newest = child.NewestMod
+            }
+        }
+    }
+
+    if commit := p.lastCommit(dir); commit.After(newest) {
+        newest = commit
+    }
+    return newest
+}
+
+// lastCommit returns the time of the last entry in the reflog of the git
+// repository holding dir, which is moved by every commit and checkout.
+func (p *projectActivity) lastCommit(dir string) time.Time {
+    gitDir := findGitDir(dir)
+    if gitDir == "" {
+        return time.Time{}
+    }
+    if t, ok := p.commits[gitDir]; ok {
+        return t
+    }
+
+    t := reflogTime(filepath.Join(gitDir, "logs", "HEAD"))
+    if t.IsZero() {
+        if info, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+            t = info.ModTime()
+        }
+    }
+    p.commits[gitDir] = t
+    return t
+}
+
+// findGitDir looks for the .git directory of dir or of a directory above
+// it, following the "gitdir:" file of worktrees and submodules.
+func findGitDir(dir string) string {
+    for {
+        git := filepath.Join(dir, ".git")
+        if info, err := os.Stat(git); err == nil {
+            if info.IsDir() {
+                return git
+            }
+            data, err := os.ReadFile(git)
+            if err != nil {
+                return ""
+            }
+            target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+            if !ok {
+                return ""
+            }
+            if !filepath.IsAbs(target) {
+                target = filepath.Join(dir, target)
+            }
+            return target
+        }
+
+        parent := filepath.Dir(dir)
+        if parent == dir {
+            return ""
+        }
+        dir = parent
+    }
+}
+
+// reflogTime reads the timestamp of the last line of a reflog, which looks
+// like "OLD NEW Name <email> 1700000000 +0100\tcommit: message".
+func reflogTime(path string) time.Time {
+    file, err := os.Open(path)
+    if err != nil {
+        return time.Time{}
+    }
+    defer file.Close()
+
+    // Only the tail is needed of what may be a long log
+    if info, err := file.Stat(); err == nil && info.Size() > 4096 {
+        file.Seek(-4096, io.SeekEnd)
+    }
+
+    var last string
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        if line := scanner.Text(); line != "" {
+            last = line
+        }
+    }
+
+    header, _, _ := strings.Cut(last, "\t")
+    fields := strings.Fields(header)
+    if len(fields) < 2 {
+        return time.Time{}
+    }
+    seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+    if err != nil {
+        return time.Time{}
+    }
+    return time.Unix(seconds, 0)
+}
+
+// hasAny returns the first of the named files that dir holds.
+func hasAny(dir string, names ...string) (string, bool) {
+    for _, name := range names {
+        if exists(filepath.Join(dir, name)) {
+            return name, true
+        }
+    }
+    return "", false
+}
@@ -0,0 +1,418 @@
+package detectors
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// Files that mark a directory as a Python project, making the environments
+// in it belong to the project.
+var pythonProjectFiles = []string{
+    "pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile",
+    "poetry.lock", "uv.lock", "tox.ini", "noxfile.py", "environment.yml",
+}
+
+// Where interpreters are looked for besides $PATH and the homes named by
+// virtualenvs, as filepath.Glob patterns: system directories, then the
+// pyenv, uv and conda installations below each user's home.
+var (
+    pythonBinDirs     = []string{"/usr/bin", "/usr/local/bin", "/opt/local/bin", "/opt/*/bin", "/opt/*/envs/*/bin"}
+    pythonUserBinDirs = []string{
+        ".pyenv/versions/*/bin", ".local/share/uv/python/*/bin",
+        "miniconda3/bin", "miniconda3/envs/*/bin", "anaconda3/bin", "anaconda3/envs/*/bin",
+        "miniforge3/bin", "miniforge3/envs/*/bin", "mambaforge/bin", "mambaforge/envs/*/bin",
+    }
+)
+
+var (
+    condaPythonPackage = regexp.MustCompile(`^python-(\d+\.\d+(?:\.\d+)?)-.*\.json$`)
+    pycVersion         = regexp.MustCompile(`\.cpython-(\d)(\d+)`)
+)
+
+// PythonDetector reports Python environments as one finding each, with
+// the version of the interpreter they were built for: virtualenvs (found
+// by pyvenv.cfg), conda environments (conda-meta/), .tox and .nox
+// directories, and __pycache__ trees. Environments whose interpreter is
+// known to be gone cannot run any more and are flagged for deletion; those
+// whose interpreter was only not found are left for review.
+type PythonDetector struct {
+    MaxSize         int64
+    CacheMinSize    int64
+    InactiveDays    int
+    CriticalAgeDays int
+    projects        *projectActivity
+    installed       map[string]bool
+    binDirs         []string
+}
+
+func NewPythonDetector(maxSize, cacheMinSize int64, inactiveDays, criticalAgeDays int, tree DirTree) *PythonDetector {
+    return &PythonDetector{
+        MaxSize:         maxSize,
+        CacheMinSize:    cacheMinSize,
+        InactiveDays:    inactiveDays,
+        CriticalAgeDays: criticalAgeDays,
+        projects:        newProjectActivity(tree),
+        installed:       make(map[string]bool),
+    }
+}
+
+// pythonEnv is what was learned about one environment. Disposable ones
+// are rebuilt by the tool that made them on its next run. Gone is set when
+// the missing interpreters are known to be removed, not just not found.
+type pythonEnv struct {
+    kind       string
+    versions   []string
+    missing    []string
+    gone       bool
+    disposable bool
+}
+
+func (d *PythonDetector) DetectDir(dir types.DirSummary) *types.ScanResult {
+    path := dir.Info.Path
+    var env *pythonEnv
+    switch name := filepath.Base(path); {
+    case name == "__pycache__":
+        return d.detectPycache(dir)
+    case name == ".tox" || name == ".nox":
+        env = d.toxEnv(path, name)
+    case exists(filepath.Join(path, "pyvenv.cfg")):
+        env = d.virtualenv(path)
+    case exists(filepath.Join(path, "conda-meta")):
+        // The base environment is the conda installation itself
+        if exists(filepath.Join(path, "condabin")) || exists(filepath.Join(path, "pkgs")) {
+            return nil
+        }
+        env = d.condaEnv(path)
+    default:
+        return nil
+    }
+
+    info := dir.Info
+    info.Size = dir.TotalSize
+    result := &types.ScanResult{
+        Info:           info,
+        Type:           types.TypePythonEnv,
+        RiskLevel:      types.RiskSafe,
+        Recommendation: types.RecKeep,
+    }
+
+    built := "unknown Python"
+    if len(env.versions) > 0 {
+        built = "Python " + strings.Join(env.versions, ", ")
+    }
+
+    if len(env.missing) > 0 {
+        result.AgeDays = int(time.Since(dir.NewestMod).Hours() / 24)
+        result.Flags = append(result.Flags, types.FlagInterpreterMissing)
+        result.RiskLevel = types.RiskCaution
+        result.Recommendation = types.RecReview
+        missing := "interpreter " + env.missing[0] + " was not found"
+        if len(env.missing) > 1 {
+            missing = "interpreters " + strings.Join(env.missing, ", ") + " were not found"
+        }
+        if env.gone {
+            if info.Size >= d.MaxSize {
+                result.RiskLevel = types.RiskCritical
+            }
+            result.Recommendation = types.RecDelete
+            missing = "interpreter " + env.missing[0] + " no longer exists"
+            if len(env.missing) > 1 {
+                missing = "interpreters " + strings.Join(env.missing, ", ") + " no longer exist"
+            }
+        }
+        result.Reason = fmt.Sprintf("%s for %s, whose %s (%s)", env.kind, built, missing, formatSize(info.Size))
+        return result
+    }
+
+    lastActive := dir.NewestMod
+    project := filepath.Dir(path)
+    if _, ok := hasAny(project, pythonProjectFiles...); ok {
+        if t := d.projects.lastActive(project, path); t.After(lastActive) {
+            lastActive = t
+        }
+    }
+    ageDays := int(time.Since(lastActive).Hours() / 24)
+    result.AgeDays = ageDays
+    if ageDays <= d.InactiveDays {
+        return result
+    }
+
+    result.RiskLevel = types.RiskCaution
+    result.Recommendation = types.RecReview
+    if info.Size >= d.MaxSize || env.disposable {
+        result.Recommendation = types.RecDelete
+    }
+    if d.CriticalAgeDays > 0 && ageDays > d.CriticalAgeDays && info.Size >= d.MaxSize {
+        result.RiskLevel = types.RiskCritical
+    }
+    result.Reason = fmt.Sprintf("%s for %s, unused for %d days (%s in %d files)",
+        env.kind, built, ageDays, formatSize(info.Size), dir.FileCount)
+    return result
+}
+
+// detectPycache reports compiled bytecode for Pythons that were not found,
+// and large caches of it. Bytecode is rebuilt when needed, but a Python
+// installed somewhere unusual may still use it.
+func (d *PythonDetector) detectPycache(dir types.DirSummary) *types.ScanResult {
+    info := dir.Info
+    info.Size = dir.TotalSize
+    result := &types.ScanResult{
+        Info:           info,
+        Type:           types.TypePycache,
+        RiskLevel:      types.RiskSafe,
+        Recommendation: types.RecKeep,
+        AgeDays:        int(time.Since(dir.NewestMod).Hours() / 24),
+    }
+
+    versions := make(map[string]bool)
+    if entries, err := os.ReadDir(info.Path); err == nil {
+        for _, entry := range entries {
+            if m := pycVersion.FindStringSubmatch(entry.Name()); m != nil {
+                versions[m[1]+"."+m[2]] = true
+            }
+        }
+    }
+
+    var installed, missing []string
+    for v := range versions {
+        if d.pythonInstalled(v) {
+            installed = append(installed, v)
+        } else {
+            missing = append(missing, v)
+        }
+    }
+    sort.Strings(missing)
+
+    switch {
+    case len(missing) > 0 && len(installed) == 0:
+        result.Flags = append(result.Flags, types.FlagInterpreterMissing)
+        result.RiskLevel = types.RiskCaution
+        result.Recommendation = types.RecReview
+        result.Reason = fmt.Sprintf("Bytecode for Python %s, which was not found on this system (%s)",
+            strings.Join(missing, ", "), formatSize(info.Size))
+    case d.CacheMinSize > 0 && info.Size >= d.CacheMinSize:
+        result.RiskLevel = types.RiskCaution
+        result.Recommendation = types.RecReview
+        result.Reason = fmt.Sprintf("Large bytecode cache (%s in %d files), rebuilt when needed",
+            formatSize(info.Size), dir.FileCount)
+    }
+    return result
+}
+
+// virtualenv reads the interpreter of a virtualenv from its pyvenv.cfg.
+func (d *PythonDetector) virtualenv(path string) *pythonEnv {
+    env := &pythonEnv{kind: "Virtualenv"}
+    cfg := readPyvenvCfg(filepath.Join(path, "pyvenv.cfg"))
+
+    version := cfg["version"]
+    if version == "" {
+        version = cfg["version_info"]
+    }
+    // "3.11.4.final.0" from virtualenv
+    if parts := strings.Split(version, "."); len(parts) > 3 {
+        version = strings.Join(parts[:3], ".")
+    }
+    if version != "" {
+        env.versions = []string{version}
+    }
+
+    home := cfg["home"]
+    if home != "" && exists(home) {
+        d.addBinDir(home)
+    }
+
+    interpreter := cfg["executable"]
+    if interpreter == "" {
+        interpreter = cfg["base-executable"]
+    }
+    switch {
+    case interpreter != "":
+        // The very file the environment runs
+        if !exists(interpreter) {
+            env.missing, env.gone = []string{interpreter}, true
+        }
+    case home != "":
+        // Only the directory is known, not the name of the interpreter
+        minor := majorMinor(version)
+        interpreter = filepath.Join(home, "python3")
+        if minor != "" {
+            interpreter = filepath.Join(home, "python"+minor)
+        }
+        if !exists(interpreter) && (minor == "" || !d.pythonInstalled(minor)) {
+            env.missing, env.gone = []string{interpreter}, !exists(home)
+        }
+    }
+    return env
+}
+
+// condaEnv finds the Python package of a conda environment, which brings
+// its own interpreter.
+func (d *PythonDetector) condaEnv(path string) *pythonEnv {
+    env := &pythonEnv{kind: "Conda environment"}
+    entries, err := os.ReadDir(filepath.Join(path, "conda-meta"))
+    if err != nil {
+        return env
+    }
+    for _, entry := range entries {
+        if m := condaPythonPackage.FindStringSubmatch(entry.Name()); m != nil {
+            env.versions = []string{m[1]}
+            interpreter := filepath.Join(path, "bin", "python")
+            if !exists(interpreter) {
+                env.missing, env.gone = []string{interpreter}, true
+            }
+            break
+        }
+    }
+    return env
+}
+
+// toxEnv gathers the virtualenvs that tox or nox keeps in one directory.
+func (d *PythonDetector) toxEnv(path, name string) *pythonEnv {
+    env := &pythonEnv{kind: "tox environments", disposable: true}
+    if name == ".nox" {
+        env.kind = "nox environments"
+    }
+
+    entries, err := os.ReadDir(path)
+    if err != nil {
+        return env
+    }
+    seen := make(map[string]bool)
+    gone := true
+    for _, entry := range entries {
+        sub := filepath.Join(path, entry.Name())
+        if !entry.IsDir() || !exists(filepath.Join(sub, "pyvenv.cfg")) {
+            continue
+        }
+        venv := d.virtualenv(sub)
+        if len(venv.missing) > 0 && !venv.gone {
+            gone = false
+        }
+        for _, v := range venv.versions {
+            if !seen[v] {
+                seen[v] = true
+                env.versions = append(env.versions, v)
+            }
+        }
+        for _, m := range venv.missing {
+            if !seen[m] {
+                seen[m] = true
+                env.missing = append(env.missing, m)
+            }
+        }
+    }
+    sort.Strings(env.versions)
+    env.gone = len(env.missing) > 0 && gone
+    return env
+}
+
+// pythonInstalled reports whether an interpreter for a "3.11" style
+// version can be found.
+func (d *PythonDetector) pythonInstalled(version string) bool {
+    installed, ok := d.installed[version]
+    if ok {
+        return installed
+    }
+
+    if d.binDirs == nil {
+        d.binDirs = pythonSearchDirs()
+    }
+    for _, dir := range d.binDirs {
+        if exists(filepath.Join(dir, "python"+version)) {
+            installed = true
+            break
+        }
+    }
+    d.installed[version] = installed
+    return installed
+}
+
+// addBinDir adds the home of a virtualenv's interpreter to the directories
+// searched, forgetting the versions not found so far.
+func (d *PythonDetector) addBinDir(dir string) {
+    if d.binDirs == nil {
+        d.binDirs = pythonSearchDirs()
+    }
+    for _, known := range d.binDirs {
+        if known == dir {
+            return
+        }
+    }
+    d.binDirs = append(d.binDirs, dir)
+    for version, installed := range d.installed {
+        if !installed {
+            delete(d.installed, version)
+        }
+    }
+}
+
+// pythonSearchDirs expands the places interpreters are looked for, $PATH
+// first.
+func pythonSearchDirs() []string {
+    var dirs []string
+    for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+        if dir != "" {
+            dirs = append(dirs, dir)
+        }
+    }
+
+    patterns := append([]string(nil), pythonBinDirs...)
+    if root := os.Getenv("PYENV_ROOT"); root != "" {
+        patterns = append(patterns, filepath.Join(root, "versions", "*", "bin"))
+    }
+    homes := []string{"/root", "/home/*"}
+    if home := os.Getenv("HOME"); home != "" {
+        homes = append(homes, home)
+    }
+    for _, home := range homes {
+        for _, dir := range pythonUserBinDirs {
+            patterns = append(patterns, filepath.Join(home, dir))
+        }
+    }
+    for _, pattern := range patterns {
+        matches, _ := filepath.Glob(pattern)
+        dirs = append(dirs, matches...)
+    }
+    return dirs
+}
+
+// readPyvenvCfg reads the "key = value" lines of a pyvenv.cfg.
+func readPyvenvCfg(path string) map[string]string {
+    cfg := make(map[string]string)
+    file, err := os.Open(path)
+    if err != nil {
+        return cfg
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        key, value, ok := strings.Cut(scanner.Text(), "=")
+        if ok {
+            cfg[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+        }
+    }
+    return cfg
+}
+
+// majorMinor turns "3.11.4" into "3.11".
+func majorMinor(version string) string {
+    parts := strings.Split(version, ".")
+    if len(parts) < 2 {
+        return ""
+    }
+    return parts[0] + "." + parts[1]
+}
+
+func exists(path string) bool {
+    _, err := os.Stat(path)
+    return err == nil
+}
@@ -0,0 +1,180 @@
+package detectors
+
+import (
+    "encoding/binary"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// TempFileDetector flags stale temporary and editor swap files, and old
+// files below the temporary directories.
+type TempFileDetector struct {
+    MaxAgeDays   int
+    DirPatterns  []string
+    FilePatterns []string
+
+    openOnce  sync.Once
+    openFiles map[string]int
+}
+
+func NewTempFileDetector(maxAgeDays int, dirPatterns, filePatterns []string) *TempFileDetector {
+    return &TempFileDetector{
+        MaxAgeDays:   maxAgeDays,
+        DirPatterns:  dirPatterns,
+        FilePatterns: filePatterns,
+    }
+}
+
+func (d *TempFileDetector) Detect(info types.FileInfo) *types.ScanResult {
+    if info.IsDir {
+        return nil
+    }
+
+    if !d.matchesFile(info.Path) && !d.inTempDir(info.Path) {
+        return nil
+    }
+
+    // Writing to a file does not always update its access time (noatime),
+    // so whichever happened last counts as the last use.
+    lastUsed := info.AccessTime
+    if info.ModTime.After(lastUsed) {
+        lastUsed = info.ModTime
+    }
+    ageDays := int(time.Since(lastUsed).Hours() / 24)
+
+    result := &types.ScanResult{
+        Info:    info,
+        Type:    types.TypeTemp,
+        AgeDays: ageDays,
+    }
+
+    if pid := d.owner(info.Path); pid > 0 {
+        result.RiskLevel = types.RiskSafe
+        result.Recommendation = types.RecKeep
+        result.Reason = fmt.Sprintf("In use by running process %d", pid)
+        return result
+    }
+
+    if ageDays > d.MaxAgeDays {
+        if info.Size > 100*1024*1024 { // 100MB
+            result.RiskLevel = types.RiskCritical
+            result.Recommendation = types.RecDelete
+        } else {
+            result.RiskLevel = types.RiskCaution
+            result.Recommendation = types.RecReview
+        }
+        result.Reason = fmt.Sprintf("Stale temporary file (unused for %d days, %s)",
+            ageDays, formatSize(info.Size))
+    } else {
+        result.RiskLevel = types.RiskSafe
+        result.Recommendation = types.RecKeep
+    }
+
+    return result
+}
+
+func (d *TempFileDetector) matchesFile(path string) bool {
+    name := strings.ToLower(filepath.Base(path))
+    for _, pattern := range d.FilePatterns {
+        if ok, _ := filepath.Match(strings.TrimSpace(pattern), name); ok {
+            return true
+        }
+    }
+    return false
+}
+
+func (d *TempFileDetector) inTempDir(path string) bool {
+    for dir := filepath.Dir(path); dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+        for _, pattern := range d.DirPatterns {
+            if matchDirPattern(dir, pattern) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// owner returns the PID of a live process that still uses path, or 0.
+// Vim swap files record their editor's PID in the header, which covers
+// editors that do not keep the swap file open.
+func (d *TempFileDetector) owner(path string) int {
+    d.openOnce.Do(func() {
+        d.openFiles = openFilesByPath()
+    })
+
+    if pid, ok := d.openFiles[path]; ok {
+        return pid
+    }
+
+    if strings.HasSuffix(path, ".swp") || strings.HasSuffix(path, ".swpx") {
+        if pid := vimSwapPID(path); pid > 0 && processAlive(pid) {
+            return pid
+        }
+    }
+
+    return 0
+}
+
+// openFilesByPath maps every file currently held open by a process to the
+// PID holding it. Processes we may not inspect are silently skipped.
+func openFilesByPath() map[string]int {
+    open := make(map[string]int)
+
+    procs, err := os.ReadDir("/proc")
+    if err != nil {
+        return open
+    }
+
+    for _, proc := range procs {
+        pid, err := strconv.Atoi(proc.Name())
+        if err != nil {
+            continue
+        }
+
+        fdDir := filepath.Join("/proc", proc.Name(), "fd")
+        fds, err := os.ReadDir(fdDir)
+        if err != nil {
+            continue
+        }
+
+        for _, fd := range fds {
+            target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+            if err == nil && strings.HasPrefix(target, "/") {
+                open[target] = pid
+            }
+        }
+    }
+
+    return open
+}
+
+// vimSwapPID reads the PID from a Vim swap file header (block 0).
+func vimSwapPID(path string) int {
+    file, err := os.Open(path)
+    if err != nil {
+        return 0
+    }
+    defer file.Close()
+
+    header := make([]byte, 28)
+    if _, err := file.ReadAt(header, 0); err != nil {
+        return 0
+    }
+    if string(header[:2]) != "b0" {
+        return 0
+    }
+
+    return int(binary.LittleEndian.Uint32(header[24:28]))
+}
+
+func processAlive(pid int) bool {
+    _, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid)))
+    return err == nil
+}
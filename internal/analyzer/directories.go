@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+    "path/filepath"
+    "sort"
+
+    "shuru-hoja/pkg/types"
+)
+
+// analyzeDirectories replaces the inode size of every directory with the
+// totals of its subtree and runs the directory detectors top-down over the
+// tree. Once a directory produces a finding its subtree is claimed by it:
+// it is not offered to the directory detectors again, and the findings of
+// the files in it are dropped, being counted by the directory's.
+func (a *Analyzer) analyzeDirectories(results []types.ScanResult) {
+    tree := a.scanner.Tree()
+
+    var order []int
+    for i, r := range results {
+        if r.Info.IsDir {
+            order = append(order, i)
+        }
+    }
+    sort.Slice(order, func(i, j int) bool {
+        return results[order[i]].Info.Path < results[order[j]].Info.Path
+    })
+
+    claimed := make(map[string]bool)
+    a.claimed = claimed
+    for _, i := range order {
+        path := results[i].Info.Path
+
+        summary, ok := tree.Get(path)
+        if !ok {
+            continue
+        }
+        summary.Info.Size = summary.TotalSize
+        summary.Info.Allocated = summary.Allocated
+        results[i].Info = summary.Info
+
+        if isClaimed(claimed, path) || a.underDocker(path) {
+            continue
+        }
+
+        for _, detector := range a.dirDetectors {
+            if result := detector.DetectDir(summary); result != nil {
+                a.annotateAtime(result)
+                results[i] = *result
+                claimed[path] = true
+                break
+            }
+        }
+    }
+
+    if len(claimed) == 0 {
+        return
+    }
+    for i, r := range results {
+        if !r.Info.IsDir && r.Type != types.TypeFile && isClaimed(claimed, r.Info.Path) {
+            results[i] = types.ScanResult{
+                Info:           r.Info,
+                Type:           types.TypeFile,
+                RiskLevel:      types.RiskSafe,
+                Recommendation: types.RecKeep,
+            }
+        }
+    }
+}
+
+func isClaimed(claimed map[string]bool, path string) bool {
+    for dir := filepath.Dir(path); dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+        if claimed[dir] {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "shuru-hoja/internal/containers"
+    "shuru-hoja/pkg/types"
+)
+
+// ContainerStorage returns the Docker storage read during the last
+// Analyze, or nil when it was not analyzed.
+func (a *Analyzer) ContainerStorage() *types.ContainerStorage {
+    return a.containers
+}
+
+// dockerRoot returns the Docker root to analyze: only when enabled, and
+// when it lies below one of the scanned roots.
+func (a *Analyzer) dockerRoot(roots []string) string {
+    if !a.config.Docker.Enabled {
+        return ""
+    }
+    root := filepath.Clean(a.config.Docker.Root)
+    for _, r := range roots {
+        if rel, err := filepath.Rel(r, root); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+            return root
+        }
+    }
+    return ""
+}
+
+// underDocker reports whether path is Docker's to judge. Its files are
+// managed by the daemon and never to be deleted by hand.
+func (a *Analyzer) underDocker(path string) bool {
+    if a.docker == "" {
+        return false
+    }
+    return path == a.docker || strings.HasPrefix(path, a.docker+"/")
+}
+
+// analyzeDocker reads Docker's storage and puts its findings in place of
+// the results for the same paths, adding those the scan did not see.
+func (a *Analyzer) analyzeDocker(ctx context.Context, results []types.ScanResult) []types.ScanResult {
+    storage, findings, err := containers.Docker(ctx, containers.Options{
+        Root:              a.docker,
+        ApparentSize:      a.config.Output.ApparentSize,
+        LogMaxSize:        a.config.Docker.LogMaxSize,
+        BuildCacheMaxSize: a.config.Detection.DockerCacheMaxSize,
+        CriticalSize:      a.config.Risk.CriticalSize,
+        CriticalAgeDays:   a.config.Risk.CriticalAgeDays,
+    }, a.scanner.Tree())
+    if err != nil {
+        err = fmt.Errorf("docker: %w", err)
+        a.errors = append(a.errors, err)
+        fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+        return results
+    }
+    a.containers = storage
+
+    index := make(map[string]int)
+    for i, r := range results {
+        if a.underDocker(r.Info.Path) {
+            index[r.Info.Path] = i
+        }
+    }
+    for _, f := range findings {
+        i, ok := index[f.Info.Path]
+        if !ok {
+            results = append(results, f)
+            continue
+        }
+        // The scan knows the file itself better
+        if !f.Info.IsDir {
+            f.Info = results[i].Info
+        }
+        results[i] = f
+    }
+    return results
+}
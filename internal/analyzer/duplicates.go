@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+    "crypto/md5"
+    "crypto/sha1"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "hash"
+    "io"
+    "os"
+    "sort"
+
+    "shuru-hoja/pkg/types"
+)
+
+// findDuplicates groups identical files and marks every copy but one for
+// review. Candidates are narrowed down by size, then by a hash of their
+// first sampleSize bytes, and only then hashed in full. Files in claimed
+// directories are left out: their directory's finding speaks for them.
+func (a *Analyzer) findDuplicates(results []types.ScanResult) {
+    minSize := a.config.Detection.DuplicateMinSize
+    sampleSize := a.config.Detection.DuplicateSampleSize
+    newHash := hashFunc(a.config.Detection.DuplicateHashMethod)
+
+    bySize := make(map[int64][]int)
+    for i, r := range results {
+        if !r.Info.Mode.IsRegular() || r.Info.Size < minSize || r.Info.Size == 0 ||
+            a.underDocker(r.Info.Path) || isClaimed(a.claimed, r.Info.Path) {
+            continue
+        }
+        bySize[r.Info.Size] = append(bySize[r.Info.Size], i)
+    }
+
+    for _, candidates := range bySize {
+        candidates = uniqueInodes(results, candidates)
+        if len(candidates) < 2 {
+            continue
+        }
+
+        for sum, sampled := range groupByHash(results, candidates, newHash, sampleSize) {
+            // Unless the sample already covered the whole file
+            groups := map[string][]int{sum: sampled}
+            if sampleSize > 0 && sampleSize < results[sampled[0]].Info.Size {
+                groups = groupByHash(results, sampled, newHash, -1)
+            }
+            for sum, group := range groups {
+                a.markDuplicates(results, group, "dup-"+sum[:12])
+            }
+        }
+    }
+}
+
+// markDuplicates keeps as the original the first copy by path that is no
+// other finding, such as a stale temporary file, so that following every
+// recommendation never removes all copies.
+func (a *Analyzer) markDuplicates(results []types.ScanResult, group []int, id string) {
+    sort.Slice(group, func(i, j int) bool {
+        x, y := results[group[i]], results[group[j]]
+        if (x.Recommendation == types.RecKeep) != (y.Recommendation == types.RecKeep) {
+            return x.Recommendation == types.RecKeep
+        }
+        return x.Info.Path < y.Info.Path
+    })
+
+    original := results[group[0]].Info.Path
+    criticalSize := a.config.Risk.CriticalSize
+
+    for n, i := range group {
+        r := &results[i]
+        r.DuplicateGroup = id
+        if n == 0 || r.Recommendation != types.RecKeep {
+            continue
+        }
+
+        r.Type = types.TypeDuplicate
+        r.Recommendation = types.RecReview
+        r.RiskLevel = types.RiskCaution
+        if criticalSize > 0 && r.Info.Size >= criticalSize {
+            r.RiskLevel = types.RiskCritical
+        }
+        r.Reason = fmt.Sprintf("Duplicate of %s (%d copies, %s each)",
+            original, len(group), formatSize(r.Info.Size))
+    }
+}
+
+// uniqueInodes drops extra paths to an inode that is already a candidate,
+// since hard links share their data and are not duplicates.
+func uniqueInodes(results []types.ScanResult, candidates []int) []int {
+    seen := make(map[fileID]bool)
+    unique := candidates[:0:0]
+    for _, i := range candidates {
+        id := fileID{results[i].Info.Device, results[i].Info.Inode}
+        if seen[id] {
+            continue
+        }
+        seen[id] = true
+        unique = append(unique, i)
+    }
+    return unique
+}
+
+// groupByHash hashes up to limit bytes of each candidate (the whole file if
+// limit is not positive) and keeps the hashes shared by more than one file.
+func groupByHash(results []types.ScanResult, candidates []int, newHash func() hash.Hash, limit int64) map[string][]int {
+    byHash := make(map[string][]int)
+    for _, i := range candidates {
+        sum, err := hashFile(results[i].Info.Path, newHash(), limit)
+        if err != nil {
+            continue
+        }
+        byHash[sum] = append(byHash[sum], i)
+    }
+
+    for sum, group := range byHash {
+        if len(group) < 2 {
+            delete(byHash, sum)
+        }
+    }
+    return byHash
+}
+
+func hashFile(path string, h hash.Hash, limit int64) (string, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+    defer file.Close()
+
+    var reader io.Reader = file
+    if limit > 0 {
+        reader = io.LimitReader(file, limit)
+    }
+
+    if _, err := io.Copy(h, reader); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFunc(method string) func() hash.Hash {
+    switch method {
+    case "sha1":
+        return sha1.New
+    case "sha256":
+        return sha256.New
+    default:
+        return md5.New
+    }
+}
+
+func formatSize(bytes int64) string {
+    const unit = 1024
+    if bytes < unit {
+        return fmt.Sprintf("%d B", bytes)
+    }
+    div, exp := int64(unit), 0
+    for n := bytes / unit; n >= unit; n /= unit {
+        div *= unit
+        exp++
+    }
+    return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,219 @@
+package analyzer
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "syscall"
+    "testing"
+
+    "shuru-hoja/internal/config"
+    "shuru-hoja/pkg/types"
+)
+
+// file is a file to create for a duplicates test. Files with the same
+// content share it; linkTo makes a hard link to an earlier file instead.
+type file struct {
+    name    string
+    content string
+    linkTo  string
+    // finding is the result another detector made of the file, if any
+    finding types.Recommendation
+}
+
+// content returns size bytes starting with head and ending with tail.
+func content(size int, head, tail byte) string {
+    data := bytes.Repeat([]byte{head}, size)
+    data[size-1] = tail
+    return string(data)
+}
+
+func TestFindDuplicates(t *testing.T) {
+    same := content(8192, 'a', 'z')
+    // Equal over the 4096 bytes sampled, different after them
+    sameStart := content(8192, 'a', 'y')
+
+    tests := []struct {
+        name       string
+        files      []file
+        sampleSize int64
+        hashMethod string
+        claimed    []string
+        // wantDuplicates are the files reported as copies, each with the
+        // original its reason names
+        wantDuplicates map[string]string
+        // wantGrouped are all files given a duplicate group
+        wantGrouped []string
+    }{
+        {
+            name:           "identical copies",
+            files:          []file{{name: "b", content: same}, {name: "a", content: same}},
+            sampleSize:     4096,
+            wantDuplicates: map[string]string{"b": "a"},
+            wantGrouped:    []string{"a", "b"},
+        },
+        {
+            name: "three copies",
+            files: []file{
+                {name: "x/c", content: same},
+                {name: "a", content: same},
+                {name: "b", content: same},
+                {name: "other", content: content(8192, 'b', 'z')},
+            },
+            sampleSize:     4096,
+            hashMethod:     "sha256",
+            wantDuplicates: map[string]string{"b": "a", "x/c": "a"},
+            wantGrouped:    []string{"a", "b", "x/c"},
+        },
+        {
+            name:       "same sample, different tail",
+            files:      []file{{name: "a", content: same}, {name: "b", content: sameStart}},
+            sampleSize: 4096,
+        },
+        {
+            name:           "sample covers the whole file",
+            files:          []file{{name: "a", content: same}, {name: "b", content: same}, {name: "c", content: sameStart}},
+            sampleSize:     1 << 20,
+            wantDuplicates: map[string]string{"b": "a"},
+            wantGrouped:    []string{"a", "b"},
+        },
+        {
+            name:           "no sampling",
+            files:          []file{{name: "a", content: same}, {name: "b", content: same}, {name: "c", content: sameStart}},
+            sampleSize:     0,
+            hashMethod:     "sha1",
+            wantDuplicates: map[string]string{"b": "a"},
+            wantGrouped:    []string{"a", "b"},
+        },
+        {
+            name:       "hard links are one file",
+            files:      []file{{name: "a", content: same}, {name: "b", linkTo: "a"}},
+            sampleSize: 4096,
+        },
+        {
+            name:       "below the minimum size",
+            files:      []file{{name: "a", content: "small"}, {name: "b", content: "small"}},
+            sampleSize: 4096,
+        },
+        {
+            name:       "copy in a claimed directory",
+            files:      []file{{name: "a", content: same}, {name: "cache/a", content: same}},
+            sampleSize: 4096,
+            claimed:    []string{"cache"},
+        },
+        {
+            name: "original is no other finding",
+            files: []file{
+                {name: "a.tmp", content: same, finding: types.RecDelete},
+                {name: "b", content: same},
+                {name: "c", content: same},
+            },
+            sampleSize:     4096,
+            wantDuplicates: map[string]string{"c": "b"},
+            wantGrouped:    []string{"a.tmp", "b", "c"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            dir := t.TempDir()
+            var results []types.ScanResult
+            for _, f := range tt.files {
+                path := filepath.Join(dir, f.name)
+                if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+                    t.Fatal(err)
+                }
+                var err error
+                if f.linkTo != "" {
+                    err = os.Link(filepath.Join(dir, f.linkTo), path)
+                } else {
+                    err = os.WriteFile(path, []byte(f.content), 0644)
+                }
+                if err != nil {
+                    t.Fatal(err)
+                }
+                results = append(results, resultOf(t, path, f.finding))
+            }
+
+            a := &Analyzer{
+                config: &config.Config{
+                    Detection: config.DetectionConfig{
+                        DuplicateMinSize:    1024,
+                        DuplicateSampleSize: tt.sampleSize,
+                        DuplicateHashMethod: tt.hashMethod,
+                    },
+                },
+                claimed: make(map[string]bool),
+            }
+            for _, c := range tt.claimed {
+                a.claimed[filepath.Join(dir, c)] = true
+            }
+            a.findDuplicates(results)
+
+            duplicates := make(map[string]string)
+            var grouped []string
+            groups := make(map[string]bool)
+            for _, r := range results {
+                name, _ := filepath.Rel(dir, r.Info.Path)
+                if r.DuplicateGroup != "" {
+                    grouped = append(grouped, name)
+                    groups[r.DuplicateGroup] = true
+                }
+                if r.Type != types.TypeDuplicate {
+                    continue
+                }
+                if r.Recommendation != types.RecReview || r.RiskLevel != types.RiskCaution {
+                    t.Errorf("%s: %s at %s risk, want Review at Caution", name, r.Recommendation, r.RiskLevel)
+                }
+                original, _, _ := strings.Cut(strings.TrimPrefix(r.Reason, "Duplicate of "+dir+"/"), " ")
+                duplicates[name] = original
+            }
+            sort.Strings(grouped)
+
+            if len(duplicates) != len(tt.wantDuplicates) {
+                t.Errorf("duplicates = %v, want %v", duplicates, tt.wantDuplicates)
+            }
+            for name, original := range tt.wantDuplicates {
+                if duplicates[name] != original {
+                    t.Errorf("%s is a duplicate of %q, want %q", name, duplicates[name], original)
+                }
+            }
+            if strings.Join(grouped, ",") != strings.Join(tt.wantGrouped, ",") {
+                t.Errorf("grouped = %v, want %v", grouped, tt.wantGrouped)
+            }
+            if len(grouped) > 0 && len(groups) != 1 {
+                t.Errorf("%d duplicate groups, want 1", len(groups))
+            }
+        })
+    }
+}
+
+// resultOf makes the scan result of path, a finding with recommendation
+// rec if that is set.
+func resultOf(t *testing.T, path string, rec types.Recommendation) types.ScanResult {
+    info, err := os.Lstat(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    stat := info.Sys().(*syscall.Stat_t)
+    result := types.ScanResult{
+        Info: types.FileInfo{
+            Path:   path,
+            Size:   info.Size(),
+            Mode:   info.Mode(),
+            Inode:  stat.Ino,
+            Device: uint64(stat.Dev),
+        },
+        Type:           types.TypeFile,
+        RiskLevel:      types.RiskSafe,
+        Recommendation: types.RecKeep,
+    }
+    if rec != "" {
+        result.Type = types.TypeTemp
+        result.RiskLevel = types.RiskCaution
+        result.Recommendation = rec
+    }
+    return result
+}
@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "shuru-hoja/pkg/types"
+)
+
+// fileID identifies an inode across all scanned filesystems.
+type fileID struct {
+    dev   uint64
+    inode uint64
+}
+
+// annotateHardLinks flags findings whose data is shared with hard links
+// that would survive deleting them, as in rsnapshot or `cp -al` trees.
+func annotateHardLinks(results []types.ScanResult) {
+    linked := false
+    for _, r := range results {
+        if r.Info.SharesData() {
+            linked = true
+            break
+        }
+    }
+    if !linked {
+        return
+    }
+
+    // All paths below a directory form a contiguous run in path order.
+    byPath := make([]int, len(results))
+    for i := range byPath {
+        byPath[i] = i
+    }
+    sort.Slice(byPath, func(i, j int) bool {
+        return results[byPath[i]].Info.Path < results[byPath[j]].Info.Path
+    })
+
+    for i := range results {
+        r := &results[i]
+        if r.Recommendation == types.RecKeep {
+            continue
+        }
+
+        if r.Info.SharesData() {
+            r.Flags = append(r.Flags, types.FlagHardLinked)
+            r.Reason = appendReason(r.Reason, fmt.Sprintf(
+                "%d hard links, deleting this path alone frees nothing", r.Info.HardLinks))
+            continue
+        }
+
+        if r.Info.IsDir {
+            annotateLinkedDir(results, byPath, r)
+        }
+    }
+}
+
+// annotateLinkedDir works out how much deleting a directory would really
+// free, given that files with links outside of it keep their data.
+func annotateLinkedDir(results []types.ScanResult, byPath []int, dir *types.ScanResult) {
+    prefix := dir.Info.Path + "/"
+    start := sort.Search(len(byPath), func(i int) bool {
+        return results[byPath[i]].Info.Path >= prefix
+    })
+
+    var freed, shared int64
+    inside := make(map[fileID]int)
+    for _, i := range byPath[start:] {
+        info := results[i].Info
+        if !strings.HasPrefix(info.Path, prefix) {
+            break
+        }
+        if info.IsDir {
+            continue
+        }
+        if !info.SharesData() {
+            freed += info.Allocated
+            continue
+        }
+
+        id := fileID{info.Device, info.Inode}
+        inside[id]++
+        switch {
+        case uint64(inside[id]) == info.HardLinks:
+            freed += info.Allocated
+            shared -= info.Allocated
+        case inside[id] == 1:
+            shared += info.Allocated
+        }
+    }
+
+    if shared <= 0 {
+        return
+    }
+
+    dir.Flags = append(dir.Flags, types.FlagHardLinked)
+    if freed == 0 {
+        dir.Reason = appendReason(dir.Reason,
+            "all files are hard-linked elsewhere, deleting it frees nothing")
+    } else {
+        dir.Reason = appendReason(dir.Reason, fmt.Sprintf(
+            "only %s would be freed, %s is hard-linked elsewhere",
+            formatSize(freed), formatSize(shared)))
+    }
+}
+
+func appendReason(reason, note string) string {
+    if reason == "" {
+        return note
+    }
+    return reason + "; " + note
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+    "sort"
+
+    "shuru-hoja/internal/mounts"
+    "shuru-hoja/pkg/types"
+)
+
+// SummarizeMounts breaks the scanned files down by the filesystem they
+// live on, largest first, and adds each filesystem's statfs capacity.
+func (a *Analyzer) SummarizeMounts(results []types.ScanResult) []types.MountSummary {
+    if a.mounts == nil {
+        return nil
+    }
+
+    apparent := a.config.Output.ApparentSize
+    byPoint := make(map[string]*types.MountSummary)
+    byDevice := make(map[uint64]*types.MountSummary)
+    seen := make(map[fileID]bool)
+
+    for _, r := range results {
+        if r.Info.IsDir {
+            continue
+        }
+
+        summary, ok := byDevice[r.Info.Device]
+        if !ok {
+            mount, found := a.mounts.Lookup(r.Info.Path)
+            if !found {
+                continue
+            }
+            summary, ok = byPoint[mount.MountPoint]
+            if !ok {
+                summary = &types.MountSummary{
+                    MountPoint: mount.MountPoint,
+                    FSType:     mount.FSType,
+                    Source:     mount.Source,
+                }
+                if usage, err := mounts.DiskUsage(mount.MountPoint); err == nil {
+                    summary.TotalBytes = usage.TotalBytes
+                    summary.UsedBytes = usage.UsedBytes
+                    summary.FreeBytes = usage.FreeBytes
+                }
+                byPoint[mount.MountPoint] = summary
+            }
+            byDevice[r.Info.Device] = summary
+        }
+
+        summary.ScannedFiles++
+        if r.Info.SharesData() {
+            id := fileID{r.Info.Device, r.Info.Inode}
+            if seen[id] {
+                continue
+            }
+            seen[id] = true
+        }
+        summary.ScannedBytes += r.Info.Usage(apparent)
+    }
+
+    var summaries []types.MountSummary
+    for _, summary := range byPoint {
+        summaries = append(summaries, *summary)
+    }
+    sort.Slice(summaries, func(i, j int) bool {
+        return summaries[i].ScannedBytes > summaries[j].ScannedBytes
+    })
+    return summaries
+}
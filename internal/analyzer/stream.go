@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+    "shuru-hoja/pkg/types"
+)
+
+// Stream makes Analyze pass every finding to emit as soon as no later pass
+// can change it, rather than only returning them at the end: once the
+// directories are analyzed, which may claim any file, and before the
+// duplicate hashing. Findings that a later pass may still change are passed
+// on once it has run.
+func (a *Analyzer) Stream(emit func(types.ScanResult)) {
+    a.emit = emit
+}
+
+// stream passes on the findings not passed on yet, with onlySettled only
+// those no later pass can change.
+func (a *Analyzer) stream(results []types.ScanResult, streamed map[int]bool, onlySettled bool) {
+    if streamed == nil {
+        return
+    }
+    for i, r := range results {
+        if r.IsFinding() && !streamed[i] && (!onlySettled || a.settled(r)) {
+            a.emit(r)
+            streamed[i] = true
+        }
+    }
+}
+
+// settled reports whether a file result is final once the directories are
+// analyzed. Directories still get their hard link annotation, as do files
+// with hard links, and files that may have copies their duplicate group.
+func (a *Analyzer) settled(r types.ScanResult) bool {
+    if r.Info.IsDir || r.Info.SharesData() {
+        return false
+    }
+    if a.config.General.Quick || !r.Info.Mode.IsRegular() || r.Info.Size == 0 {
+        return true
+    }
+    return r.Info.Size < a.config.Detection.DuplicateMinSize
+}
@@ -0,0 +1,462 @@
+// Package browse is a full-screen terminal browser over the directory tree
+// and findings of a scan, in the manner of ncdu.
+package browse
+
+import (
+    "encoding/csv"
+    "fmt"
+    "os"
+    "os/signal"
+    "sort"
+    "strconv"
+    "strings"
+    "syscall"
+    "unicode/utf8"
+
+    "shuru-hoja/internal/snapshot"
+    "shuru-hoja/internal/ui"
+    "shuru-hoja/pkg/types"
+)
+
+var risks = []types.RiskLevel{types.RiskCaution, types.RiskCritical}
+
+const helpLine = "↑↓ move  →/enter open  ← back  s/a/c sort  t type  r risk  x clear  space mark  e export  ? help  q quit"
+
+var helpText = []string{
+    "Navigation",
+    "  ↑ ↓ k j          move the cursor",
+    "  PgUp PgDn        move a page",
+    "  Home End g G     first or last entry",
+    "  → enter l        open the directory",
+    "  ← backspace h    back to the parent directory",
+    "",
+    "Listing",
+    "  s                sort by size, largest first",
+    "  a                sort by age, oldest first",
+    "  c                sort by number of files",
+    "  t                filter by finding type, press again for the next",
+    "  r                filter by risk: Caution, Critical",
+    "  x                clear the filters",
+    "",
+    "Review",
+    "  space            mark or unmark the entry",
+    "  e                export the marked entries as a review list",
+    "  q                quit",
+}
+
+// browser is the state of one browsing session.
+type browser struct {
+    term       *terminal
+    root, dir  *node
+    list       []*node
+    cursor     int
+    offset     int
+    order      int
+    filter     filter
+    kinds      []types.FileType
+    marked     map[*node]bool
+    exportPath string
+    message    string
+    help       bool
+    width      int
+    height     int
+}
+
+// Run browses a snapshot until the user quits. Marked entries are exported
+// to exportPath as CSV.
+func Run(snap *snapshot.Snapshot, exportPath string) error {
+    term, err := openTerminal(os.Stdin, os.Stdout)
+    if err != nil {
+        return err
+    }
+    defer term.close()
+
+    root := buildTree(snap)
+    b := &browser{
+        term:       term,
+        root:       root,
+        dir:        root,
+        kinds:      findingTypes(root),
+        marked:     make(map[*node]bool),
+        exportPath: exportPath,
+    }
+    b.relist(nil)
+
+    keys := make(chan rune, 16)
+    go term.readKeys(keys)
+    winch := make(chan os.Signal, 1)
+    signal.Notify(winch, syscall.SIGWINCH)
+    defer signal.Stop(winch)
+
+    for {
+        b.draw()
+        select {
+        case key, ok := <-keys:
+            if !ok || !b.handle(key) {
+                return nil
+            }
+        case <-winch:
+        }
+    }
+}
+
+// handle acts on one key and reports whether to go on.
+func (b *browser) handle(key rune) bool {
+    b.message = ""
+    if b.help {
+        b.help = false
+        return key != 'q' && key != keyCtrlC
+    }
+
+    page := b.height - 5
+    switch key {
+    case 'q', keyCtrlC:
+        return false
+    case '?':
+        b.help = true
+    case keyUp, 'k':
+        b.move(-1)
+    case keyDown, 'j':
+        b.move(1)
+    case keyPageUp:
+        b.move(-page)
+    case keyPageDown:
+        b.move(page)
+    case keyHome, 'g':
+        b.move(-len(b.list))
+    case keyEnd, 'G':
+        b.move(len(b.list))
+    case keyRight, keyEnter, 'l':
+        if n := b.selected(); n != nil && n.isDir && len(n.children) > 0 {
+            b.dir = n
+            b.relist(nil)
+        }
+    case keyLeft, keyBackspace, 'h':
+        if b.dir.parent != nil {
+            from := b.dir
+            b.dir = b.dir.parent
+            b.relist(from)
+        }
+    case 's', 'a', 'c':
+        b.order = strings.IndexRune("sac", key)
+        b.relist(b.selected())
+    case 't':
+        b.filter.kind = nextKind(b.kinds, b.filter.kind)
+        b.refilter()
+    case 'r':
+        b.filter.risk = nextRisk(b.filter.risk)
+        b.refilter()
+    case 'x':
+        b.filter = filter{}
+        b.refilter()
+    case ' ':
+        if n := b.selected(); n != nil {
+            if b.marked[n] {
+                delete(b.marked, n)
+            } else {
+                b.marked[n] = true
+            }
+            b.move(1)
+        }
+    case 'e':
+        b.export()
+    case keyEscape:
+    }
+    return true
+}
+
+func nextKind(kinds []types.FileType, current types.FileType) types.FileType {
+    for i, k := range kinds {
+        if k == current && i+1 < len(kinds) {
+            return kinds[i+1]
+        }
+    }
+    if current == "" && len(kinds) > 0 {
+        return kinds[0]
+    }
+    return ""
+}
+
+func nextRisk(current types.RiskLevel) types.RiskLevel {
+    for i, r := range risks {
+        if r == current && i+1 < len(risks) {
+            return risks[i+1]
+        }
+    }
+    if current == "" {
+        return risks[0]
+    }
+    return ""
+}
+
+func (b *browser) refilter() {
+    b.filter.apply(b.root)
+    b.relist(b.selected())
+}
+
+// relist reads the current directory again and puts the cursor on keep if
+// it is listed.
+func (b *browser) relist(keep *node) {
+    b.list = listing(b.dir, b.filter, b.order)
+    b.cursor, b.offset = 0, 0
+    for i, n := range b.list {
+        if n == keep {
+            b.cursor = i
+        }
+    }
+}
+
+func (b *browser) selected() *node {
+    if b.cursor < len(b.list) {
+        return b.list[b.cursor]
+    }
+    return nil
+}
+
+func (b *browser) move(delta int) {
+    b.cursor += delta
+    if b.cursor >= len(b.list) {
+        b.cursor = len(b.list) - 1
+    }
+    if b.cursor < 0 {
+        b.cursor = 0
+    }
+}
+
+// export writes the marked entries as CSV, largest first.
+func (b *browser) export() {
+    if len(b.marked) == 0 {
+        b.message = "Nothing marked; press space to mark entries"
+        return
+    }
+
+    var nodes []*node
+    for n := range b.marked {
+        nodes = append(nodes, n)
+    }
+    sort.Slice(nodes, func(i, j int) bool {
+        if nodes[i].size != nodes[j].size {
+            return nodes[i].size > nodes[j].size
+        }
+        return nodes[i].path < nodes[j].path
+    })
+
+    file, err := os.Create(b.exportPath)
+    if err != nil {
+        b.message = "Export failed: " + err.Error()
+        return
+    }
+    w := csv.NewWriter(file)
+    w.Write([]string{"path", "is_dir", "size", "type", "risk", "reason"})
+    for _, n := range nodes {
+        w.Write([]string{n.path, strconv.FormatBool(n.isDir), strconv.FormatInt(n.size, 10),
+            string(n.kind), string(n.risk), n.reason})
+    }
+    w.Flush()
+    err = w.Error()
+    if cerr := file.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        b.message = "Export failed: " + err.Error()
+        return
+    }
+    b.message = fmt.Sprintf("Exported %d entries to %s", len(nodes), b.exportPath)
+}
+
+// draw paints the whole screen.
+func (b *browser) draw() {
+    width, height, err := b.term.size()
+    if err != nil {
+        return
+    }
+    b.width, b.height = width, height
+
+    var lines []string
+    lines = append(lines, "\x1b[7m"+pad(" shuru-hoja browse  "+b.title(), width)+"\x1b[0m")
+    lines = append(lines, pad(b.status(), width))
+
+    rows := height - 5
+    if b.help {
+        for i := 0; i < rows; i++ {
+            line := ""
+            if i < len(helpText) {
+                line = "  " + helpText[i]
+            }
+            lines = append(lines, pad(line, width))
+        }
+    } else {
+        lines = append(lines, b.listLines(rows)...)
+    }
+
+    lines = append(lines, b.details(width)...)
+    footer := helpLine
+    if b.message != "" {
+        footer = b.message
+    }
+    lines = append(lines, "\x1b[7m"+pad(" "+footer, width)+"\x1b[0m")
+
+    var out strings.Builder
+    out.WriteString("\x1b[H")
+    for i, line := range lines {
+        if i >= height {
+            break
+        }
+        if i > 0 {
+            out.WriteString("\r\n")
+        }
+        out.WriteString(line)
+    }
+    b.term.out.WriteString(out.String())
+}
+
+// title names the current directory, or the roots at the top of a scan of
+// several.
+func (b *browser) title() string {
+    if b.dir.path == "" {
+        return b.dir.name
+    }
+    return b.dir.path
+}
+
+func (b *browser) status() string {
+    size := b.dir.size
+    what := "in"
+    if b.filter.active() {
+        size = b.dir.matched
+        what = "of findings in"
+    }
+    kind, risk := string(b.filter.kind), string(b.filter.risk)
+    if kind == "" {
+        kind = "all"
+    }
+    if risk == "" {
+        risk = "all"
+    }
+    return fmt.Sprintf(" %s %s %d files   sort: %s   type: %s   risk: %s   marked: %d",
+        ui.FormatSize(size), what, b.dir.files, orderNames[b.order], kind, risk, len(b.marked))
+}
+
+// listLines draws the visible part of the listing.
+func (b *browser) listLines(rows int) []string {
+    if b.cursor < b.offset {
+        b.offset = b.cursor
+    }
+    if b.cursor >= b.offset+rows {
+        b.offset = b.cursor - rows + 1
+    }
+
+    var largest int64
+    for _, n := range b.list {
+        largest = max(largest, b.size(n))
+    }
+
+    var lines []string
+    for i := b.offset; i < b.offset+rows; i++ {
+        if i >= len(b.list) {
+            line := ""
+            if len(b.list) == 0 && i == 0 {
+                line = "  (nothing to show)"
+            }
+            lines = append(lines, pad(line, b.width))
+            continue
+        }
+
+        n := b.list[i]
+        mark := " "
+        if b.marked[n] {
+            mark = "*"
+        }
+        filled := 0
+        if largest > 0 {
+            filled = int(b.size(n) * 10 / largest)
+        }
+        name := n.name
+        if n.isDir {
+            name += "/"
+        }
+        tag := ""
+        if n.kind != "" {
+            tag = " [" + describe(n) + "]"
+        }
+
+        prefix := fmt.Sprintf(" %s %10s [%-10s] %8d  %s  ", mark, ui.FormatSize(b.size(n)),
+            strings.Repeat("#", filled), n.files, n.modTime.Format("2006-01-02"))
+        room := b.width - utf8.RuneCountInString(prefix) - utf8.RuneCountInString(tag)
+        line := pad(prefix+fit(name, room)+tag, b.width)
+
+        switch {
+        case i == b.cursor:
+            line = "\x1b[7m" + line + "\x1b[0m"
+        case n.risk == types.RiskCritical:
+            line = ui.ColorRed + line + ui.ColorReset
+        case n.risk == types.RiskCaution:
+            line = ui.ColorYellow + line + ui.ColorReset
+        }
+        lines = append(lines, line)
+    }
+    return lines
+}
+
+func (b *browser) size(n *node) int64 {
+    if b.filter.active() {
+        return n.matched
+    }
+    return n.size
+}
+
+// details describes the selected entry on two lines.
+func (b *browser) details(width int) []string {
+    n := b.selected()
+    if n == nil || b.help {
+        return []string{pad("", width), pad("", width)}
+    }
+
+    info := fmt.Sprintf(" Modified %s", n.modTime.Format("2006-01-02 15:04"))
+    if n.kind != "" {
+        info = fmt.Sprintf(" %s.%s", describe(n), info)
+        if n.reason != "" {
+            info += "  " + n.reason
+        }
+    } else if b.filter.active() {
+        info = fmt.Sprintf(" %d matching findings below.%s", n.hits, info)
+    }
+    return []string{pad(" "+fit(n.path, width-1), width), pad(fit(info, width), width)}
+}
+
+// describe names the kind of a finding and its risk, which snapshots made
+// by older versions do not record.
+func describe(n *node) string {
+    if n.risk == "" {
+        return string(n.kind)
+    }
+    return fmt.Sprintf("%s, %s risk", n.kind, n.risk)
+}
+
+// fit shortens s to at most n characters. Control characters, which file
+// names may contain, are shown as '?' so that they cannot move the cursor.
+func fit(s string, n int) string {
+    if n <= 0 {
+        return ""
+    }
+    runes := []rune(s)
+    for i, r := range runes {
+        if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+            runes[i] = '?'
+        }
+    }
+    s = string(runes)
+    if len(runes) <= n {
+        return s
+    }
+    return string(runes[:n-1]) + "…"
+}
+
+// pad fits s to exactly n characters, clearing the rest of the line.
+func pad(s string, n int) string {
+    s = fit(s, n)
+    if count := utf8.RuneCountInString(s); count < n {
+        s += strings.Repeat(" ", n-count)
+    }
+    return s
+}
@@ -0,0 +1,152 @@
+package browse
+
+import (
+    "errors"
+    "os"
+    "syscall"
+    "unicode/utf8"
+    "unsafe"
+)
+
+// terminal puts a tty into raw mode on an alternate screen and restores it
+// on close.
+type terminal struct {
+    in, out *os.File
+    saved   syscall.Termios
+}
+
+var errNotTerminal = errors.New("browse needs an interactive terminal")
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+    var termios syscall.Termios
+    return ioctl(f.Fd(), syscall.TCGETS, unsafe.Pointer(&termios)) == nil
+}
+
+func openTerminal(in, out *os.File) (*terminal, error) {
+    t := &terminal{in: in, out: out}
+    if err := ioctl(in.Fd(), syscall.TCGETS, unsafe.Pointer(&t.saved)); err != nil {
+        return nil, errNotTerminal
+    }
+    if _, _, err := t.size(); err != nil {
+        return nil, errNotTerminal
+    }
+
+    raw := t.saved
+    raw.Iflag &^= syscall.ICRNL | syscall.IXON | syscall.BRKINT | syscall.INPCK | syscall.ISTRIP
+    raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+    raw.Cflag |= syscall.CS8
+    raw.Cc[syscall.VMIN] = 1
+    raw.Cc[syscall.VTIME] = 0
+    if err := ioctl(in.Fd(), syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+        return nil, err
+    }
+
+    // Alternate screen, hidden cursor
+    out.WriteString("\x1b[?1049h\x1b[?25l")
+    return t, nil
+}
+
+func (t *terminal) close() {
+    t.out.WriteString("\x1b[?25h\x1b[?1049l")
+    ioctl(t.in.Fd(), syscall.TCSETS, unsafe.Pointer(&t.saved))
+}
+
+// size returns the width and height of the terminal.
+func (t *terminal) size() (int, int, error) {
+    var ws struct {
+        rows, cols, xpixel, ypixel uint16
+    }
+    if err := ioctl(t.out.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+        return 0, 0, err
+    }
+    if ws.cols == 0 || ws.rows == 0 {
+        return 0, 0, errNotTerminal
+    }
+    return int(ws.cols), int(ws.rows), nil
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+    _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+    if errno != 0 {
+        return errno
+    }
+    return nil
+}
+
+// Keys that are not printable characters.
+const (
+    keyUp rune = -(iota + 1)
+    keyDown
+    keyLeft
+    keyRight
+    keyPageUp
+    keyPageDown
+    keyHome
+    keyEnd
+    keyEnter
+    keyBackspace
+    keyEscape
+    keyCtrlC
+)
+
+// readKeys sends every key pressed to keys until reading fails.
+func (t *terminal) readKeys(keys chan<- rune) {
+    buf := make([]byte, 64)
+    for {
+        n, err := t.in.Read(buf)
+        if err != nil {
+            close(keys)
+            return
+        }
+        for _, k := range parseKeys(buf[:n]) {
+            keys <- k
+        }
+    }
+}
+
+// parseKeys decodes one read from the terminal. Escape sequences arrive in
+// a single read, so an escape at the end of the input stands for itself.
+func parseKeys(b []byte) []rune {
+    sequences := map[string]rune{
+        "[A": keyUp, "[B": keyDown, "[C": keyRight, "[D": keyLeft,
+        "OA": keyUp, "OB": keyDown, "OC": keyRight, "OD": keyLeft,
+        "[5~": keyPageUp, "[6~": keyPageDown,
+        "[H": keyHome, "[F": keyEnd, "OH": keyHome, "OF": keyEnd,
+        "[1~": keyHome, "[4~": keyEnd, "[7~": keyHome, "[8~": keyEnd,
+    }
+
+    var keys []rune
+    for len(b) > 0 {
+        switch c := b[0]; {
+        case c == 0x1b:
+            matched := false
+            for seq, key := range sequences {
+                if len(b) > len(seq) && string(b[1:1+len(seq)]) == seq {
+                    keys = append(keys, key)
+                    b = b[1+len(seq):]
+                    matched = true
+                    break
+                }
+            }
+            if !matched {
+                keys = append(keys, keyEscape)
+                b = b[1:]
+            }
+        case c == '\r' || c == '\n':
+            keys = append(keys, keyEnter)
+            b = b[1:]
+        case c == 0x7f || c == 0x08:
+            keys = append(keys, keyBackspace)
+            b = b[1:]
+        case c == 0x03:
+            keys = append(keys, keyCtrlC)
+            b = b[1:]
+        default:
+            r, size := utf8.DecodeRune(b)
+            keys = append(keys, r)
+            b = b[size:]
+        }
+    }
+    return keys
+}
@@ -0,0 +1,219 @@
+package browse
+
+import (
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "shuru-hoja/internal/snapshot"
+    "shuru-hoja/pkg/types"
+)
+
+// node is a file or directory of the browsed tree. The size of a directory
+// is that of everything below it.
+type node struct {
+    name     string
+    path     string
+    isDir    bool
+    size     int64
+    modTime  time.Time
+    files    int64
+    parent   *node
+    children []*node
+
+    // Set for findings
+    kind   types.FileType
+    risk   types.RiskLevel
+    reason string
+
+    // matched is the size of the findings below that pass the filter
+    matched int64
+    hits    int
+}
+
+// buildTree arranges the entries of a snapshot below their scan roots. With
+// several roots the top of the tree is a node holding all of them.
+func buildTree(snap *snapshot.Snapshot) *node {
+    nodes := make(map[string]*node, len(snap.Entries))
+    isRoot := make(map[string]bool, len(snap.Roots))
+    for _, root := range snap.Roots {
+        isRoot[root] = true
+    }
+
+    // get returns the node of a path, creating it and its parents as far
+    // up as the root it is below
+    var get func(path string) *node
+    get = func(path string) *node {
+        if n, ok := nodes[path]; ok {
+            return n
+        }
+        n := &node{name: filepath.Base(path), path: path, isDir: true}
+        nodes[path] = n
+        parent := filepath.Dir(path)
+        if !isRoot[path] && parent != path {
+            n.parent = get(parent)
+            n.parent.children = append(n.parent.children, n)
+        }
+        return n
+    }
+
+    sized := make(map[*node]bool, len(snap.Entries))
+    for _, e := range snap.Entries {
+        n := get(e.Path)
+        n.isDir = e.IsDir
+        n.size = e.Usage(snap.ApparentSize)
+        n.modTime = time.Unix(0, e.ModTime)
+        n.kind = e.Type
+        n.risk = e.Risk
+        n.reason = e.Reason
+        sized[n] = true
+    }
+
+    var roots []*node
+    for _, root := range snap.Roots {
+        if n, ok := nodes[root]; ok {
+            roots = append(roots, n)
+        }
+    }
+    for _, n := range roots {
+        total(n, sized)
+    }
+    if len(roots) == 1 {
+        roots[0].name = roots[0].path
+        return roots[0]
+    }
+
+    top := &node{name: strings.Join(snap.Roots, " "), isDir: true, children: roots}
+    for _, n := range roots {
+        n.name = n.path
+        n.parent = top
+        top.size += n.size
+        top.files += n.files
+        if n.modTime.After(top.modTime) {
+            top.modTime = n.modTime
+        }
+    }
+    return top
+}
+
+// total fills in the file count and newest modification of a subtree, and
+// the size of directories that had no entry of their own.
+func total(n *node, sized map[*node]bool) {
+    if !n.isDir {
+        n.files = 1
+        return
+    }
+    var size int64
+    for _, c := range n.children {
+        total(c, sized)
+        size += c.size
+        n.files += c.files
+        if c.modTime.After(n.modTime) {
+            n.modTime = c.modTime
+        }
+    }
+    if !sized[n] {
+        n.size = size
+    }
+}
+
+// filter limits the tree to findings of one type, risk or both.
+type filter struct {
+    kind types.FileType
+    risk types.RiskLevel
+}
+
+func (f filter) active() bool {
+    return f.kind != "" || f.risk != ""
+}
+
+func (f filter) match(n *node) bool {
+    return n.kind != "" && (f.kind == "" || n.kind == f.kind) && (f.risk == "" || n.risk == f.risk)
+}
+
+// apply works out the size of the matching findings below every node. A
+// matching directory counts whole, without looking inside it again.
+func (f filter) apply(n *node) {
+    n.matched, n.hits = 0, 0
+    for _, c := range n.children {
+        f.apply(c)
+    }
+    if f.match(n) {
+        n.matched, n.hits = n.size, 1
+        return
+    }
+    for _, c := range n.children {
+        n.matched += c.matched
+        n.hits += c.hits
+    }
+}
+
+// Orders the listing can be sorted in.
+const (
+    bySize = iota
+    byAge
+    byCount
+)
+
+var orderNames = []string{"size", "age", "count"}
+
+// listing returns the children of n to show, sorted.
+func listing(n *node, f filter, order int) []*node {
+    var list []*node
+    for _, c := range n.children {
+        if !f.active() || c.hits > 0 {
+            list = append(list, c)
+        }
+    }
+
+    size := func(n *node) int64 {
+        if f.active() {
+            return n.matched
+        }
+        return n.size
+    }
+    sort.SliceStable(list, func(i, j int) bool {
+        a, b := list[i], list[j]
+        switch order {
+        case byAge:
+            if !a.modTime.Equal(b.modTime) {
+                return a.modTime.Before(b.modTime)
+            }
+        case byCount:
+            if a.files != b.files {
+                return a.files > b.files
+            }
+        default:
+            if size(a) != size(b) {
+                return size(a) > size(b)
+            }
+        }
+        return a.name < b.name
+    })
+    return list
+}
+
+// findingTypes returns the types of the findings in a tree, in the order
+// of types.FileTypes.
+func findingTypes(n *node) []types.FileType {
+    present := make(map[types.FileType]bool)
+    var walk func(n *node)
+    walk = func(n *node) {
+        if n.kind != "" {
+            present[n.kind] = true
+        }
+        for _, c := range n.children {
+            walk(c)
+        }
+    }
+    walk(n)
+
+    var kinds []types.FileType
+    for _, t := range types.FileTypes {
+        if present[t] {
+            kinds = append(kinds, t)
+        }
+    }
+    return kinds
+}
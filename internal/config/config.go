@@ -4,7 +4,7 @@ import (
     "fmt"
     "os"
     "path/filepath"
-    "strconv"
+    "sort"
     "strings"
 )
 
@@ -14,165 +14,395 @@ type Config struct {
     Risk         RiskConfig
     Output       OutputConfig
     Safety       SafetyConfig
+    Daemon       DaemonConfig
+    Snapshot     SnapshotConfig
+    Docker       DockerConfig
+    
+    // origins records which layer last set each "section.key"
+    origins map[string]string
+    // warnings are the deprecated settings met while loading
+    warnings Errors
 }
 
 type GeneralConfig struct {
-    MaxWorkers int
-    SkipPaths  []string
-    MaxDepth   int
+    MaxWorkers    int
+    SkipPaths     []string
+    MaxDepth      int
+    OneFileSystem bool
+    SkipFSTypes   []string
+    Quick         bool
 }
 
 type DetectionConfig struct {
     LogFileAgeDays      int
+    LogFilePatterns     []string
+    TempFileAgeDays     int
+    TempDirPatterns     []string
+    TempFilePatterns    []string
     OrphanDirAgeDays    int
     OrphanDirMinSize    int64
+    OrphanAccessCheck   bool
     CacheMinSize        int64
+    CacheDirPatterns    []string
     DuplicateMinSize    int64
+    DuplicateSampleSize int64
+    DuplicateHashMethod string
     NodeModulesMaxSize  int64
+    // ProjectInactiveDays is how long a project may go untouched before
+    // its dependencies are suggested for cleanup
+    ProjectInactiveDays int
     PythonVenvMaxSize   int64
     DockerCacheMaxSize  int64
     JournalLogMaxSize   int64
 }
 
 type RiskConfig struct {
-    CriticalSizeGB int64
-    CautionSizeGB  int64
+    CriticalSize    int64
     CriticalAgeDays int
-    CautionAgeDays  int
 }
 
 type OutputConfig struct {
-    Format            string
-    Color             bool
-    MaxResults        int
-    TruncatePathLength int
+    Format              string
+    Color               bool
+    MaxResults          int
+    SortBy              string
+    SortOrder           string
+    TruncatePathLength  int
+    ShowFullPath        bool
+    ShowSummary         bool
+    ShowRecommendations bool
+    ShowStatistics      bool
+    ApparentSize        bool
+    PrometheusTopPaths  int
 }
 
 type SafetyConfig struct {
-    ReadOnly         bool
-    MaxFileSize      int64
-    SkipSystemDirs   bool
-    MaxMemoryMB      int
-    MaxOpenFiles     int
+    PermissionWarnings bool
+    ScanTimeoutMinutes int
+}
+
+// DaemonConfig controls "shuru-hoja serve".
+type DaemonConfig struct {
+    // Listen is "unix:PATH" or a loopback "host:port"
+    Listen          string
+    IntervalMinutes int
+    Roots           []string
+    // StateFile keeps the latest report across restarts, "" keeps it in
+    // memory only
+    StateFile       string
+}
+
+// SnapshotConfig controls the snapshots kept to compare scans with "diff".
+type SnapshotConfig struct {
+    Enabled bool
+    Dir     string
+    // Keep is the number of snapshots kept, 0 keeps all of them
+    Keep    int
 }
 
-func Load() (*Config, error) {
+// DockerConfig controls the analysis of Docker's storage, read from its
+// files under Root.
+type DockerConfig struct {
+    Enabled    bool
+    Root       string
+    // LogMaxSize is the size from which a running container's log is
+    // reported
+    LogMaxSize int64
+}
+
+const (
+    systemFile = "/etc/shuruhoja.conf"
+    systemDir  = "/etc/shuruhoja.d"
+    envPrefix  = "SHURUHOJA_"
+)
+
+// Value is the effective value of one setting and the layer it came from.
+type Value struct {
+    Name   string
+    Value  string
+    Origin string
+}
+
+// Load builds the configuration from its layers, each overriding the ones
+// before it: built-in defaults, /etc/shuruhoja.conf, /etc/shuruhoja.d/*.conf
+// in name order, the user's config, file (from --config) and finally
+// SHURUHOJA_<SECTION>_<KEY> environment variables. Command-line flags are
+// applied on top by the caller with Set.
+//
+// Optional files that do not exist are skipped. Every invalid setting in
+// any layer is reported in the returned Errors; deprecated ones are only
+// reported by Warnings.
+func Load(file string) (*Config, error) {
     cfg := defaultConfig()
+    var errs Errors
     
-    // Try to load from /etc/shuruhoja.conf
-    if err := loadFromFile("/etc/shuruhoja.conf", cfg); err != nil {
-        // Try local config
-        home, _ := os.UserHomeDir()
-        localConfig := filepath.Join(home, ".config", "shuruhoja.conf")
-        if err := loadFromFile(localConfig, cfg); err != nil {
-            // Use defaults
-            fmt.Println("Using default configuration")
+    layers := []string{systemFile}
+    if dropIns, err := filepath.Glob(filepath.Join(systemDir, "*.conf")); err == nil {
+        sort.Strings(dropIns)
+        layers = append(layers, dropIns...)
+    }
+    if userFile := userConfigPath(); userFile != "" {
+        layers = append(layers, userFile)
+    }
+    
+    for _, layer := range layers {
+        if err := loadFromFile(layer, cfg); err != nil && !os.IsNotExist(err) {
+            errs = appendError(errs, layer, err)
         }
     }
     
+    if file != "" {
+        if err := loadFromFile(file, cfg); err != nil {
+            errs = appendError(errs, file, err)
+        }
+    }
+    
+    errs = append(errs, loadFromEnv(cfg)...)
+    
+    if len(errs) > 0 {
+        return nil, errs
+    }
     return cfg, nil
 }
 
+// Set applies a value to the setting called name ("section.key") and
+// records origin as where it came from.
+func (c *Config) Set(name, value, origin string) error {
+    s := lookupName(name)
+    if s == nil {
+        if warning := deprecatedWarning(name); warning != nil {
+            c.warnings = append(c.warnings, &ParseError{File: origin, Err: warning})
+            return nil
+        }
+        return fmt.Errorf("unknown setting %q", name)
+    }
+    if err := s.set(c, value); err != nil {
+        return fmt.Errorf("%s: %v", name, err)
+    }
+    c.setOrigin(s.name(), origin)
+    return nil
+}
+
+// Values lists every setting with its effective value and origin, in the
+// order they are documented.
+func (c *Config) Values() []Value {
+    values := make([]Value, len(settings))
+    for i := range settings {
+        name := settings[i].name()
+        origin := c.origins[name]
+        if origin == "" {
+            origin = "default"
+        }
+        values[i] = Value{Name: name, Value: settings[i].get(c), Origin: origin}
+    }
+    return values
+}
+
+// Warnings returns the deprecated settings met while loading, which were
+// accepted rather than rejected.
+func (c *Config) Warnings() Errors {
+    return c.warnings
+}
+
+func (c *Config) setOrigin(name, origin string) {
+    if c.origins == nil {
+        c.origins = make(map[string]string)
+    }
+    c.origins[name] = origin
+}
+
+func userConfigPath() string {
+    if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+        return filepath.Join(dir, "shuruhoja.conf")
+    }
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return ""
+    }
+    return filepath.Join(home, ".config", "shuruhoja.conf")
+}
+
+func loadFromEnv(cfg *Config) Errors {
+    var errs Errors
+    for _, entry := range os.Environ() {
+        variable, value, _ := strings.Cut(entry, "=")
+        if !strings.HasPrefix(variable, envPrefix) {
+            continue
+        }
+        
+        origin := "env " + variable
+        s := lookupEnv(variable)
+        if s == nil {
+            if name, ok := deprecatedEnv(variable); ok {
+                cfg.warnings = append(cfg.warnings, &ParseError{File: origin, Err: deprecatedWarning(name)})
+            } else {
+                errs = append(errs, &ParseError{File: origin, Err: fmt.Errorf("unknown setting")})
+            }
+            continue
+        }
+        if err := s.set(cfg, strings.TrimSpace(value)); err != nil {
+            errs = append(errs, &ParseError{File: origin, Err: fmt.Errorf("%s: %v", s.name(), err)})
+            continue
+        }
+        cfg.setOrigin(s.name(), origin)
+    }
+    return errs
+}
+
+func appendError(errs Errors, file string, err error) Errors {
+    if fileErrs, ok := err.(Errors); ok {
+        return append(errs, fileErrs...)
+    }
+    return append(errs, &ParseError{File: file, Err: err})
+}
+
 func defaultConfig() *Config {
     return &Config{
         General: GeneralConfig{
-            MaxWorkers: 100,
-            SkipPaths:  []string{"/proc", "/sys", "/dev", "/run"},
-            MaxDepth:   0, // Unlimited
+            MaxWorkers:    100,
+            SkipPaths:     []string{"/proc", "/sys", "/dev", "/run", ".snapshot", ".zfs"},
+            MaxDepth:      0, // Unlimited
+            OneFileSystem: false,
+            SkipFSTypes:   []string{"nfs", "nfs4", "cifs", "smb3", "fuse.sshfs", "overlay", "squashfs"},
         },
         Detection: DetectionConfig{
             LogFileAgeDays:      30,
+            LogFilePatterns:     []string{"*.log", "*.log.*", "*.gz", "*.bz2"},
+            TempFileAgeDays:     7,
+            TempDirPatterns:     []string{"/tmp/", "/var/tmp/", "~/.tmp/"},
+            TempFilePatterns:    []string{"*.tmp", "*.temp", "*.swp", "*.swpx"},
             OrphanDirAgeDays:    90,
             OrphanDirMinSize:    1 * 1024 * 1024 * 1024, // 1GB
+            OrphanAccessCheck:   true,
             CacheMinSize:        100 * 1024 * 1024,      // 100MB
+            CacheDirPatterns:    []string{".cache", "/var/cache", "~/.npm", "~/.gradle", "~/.m2/repository", "~/.cargo/registry", "~/.yarn/cache"},
             DuplicateMinSize:    10 * 1024 * 1024,       // 10MB
+            DuplicateSampleSize: 4096,
+            DuplicateHashMethod: "md5",
             NodeModulesMaxSize:  500 * 1024 * 1024,      // 500MB
+            ProjectInactiveDays: 90,
             PythonVenvMaxSize:   1 * 1024 * 1024 * 1024, // 1GB
             DockerCacheMaxSize:  5 * 1024 * 1024 * 1024, // 5GB
             JournalLogMaxSize:   2 * 1024 * 1024 * 1024, // 2GB
         },
         Risk: RiskConfig{
-            CriticalSizeGB: 10,
-            CautionSizeGB:  1,
+            CriticalSize:    10 * 1024 * 1024 * 1024, // 10GB
             CriticalAgeDays: 365,
-            CautionAgeDays:  180,
         },
         Output: OutputConfig{
-            Format:            "table",
-            Color:             true,
-            MaxResults:        50,
-            TruncatePathLength: 80,
+            Format:              "table",
+            Color:               true,
+            MaxResults:          50,
+            SortBy:              "size",
+            SortOrder:           "desc",
+            TruncatePathLength:  80,
+            ShowFullPath:        false,
+            ShowSummary:         true,
+            ShowRecommendations: true,
+            ShowStatistics:      true,
+            PrometheusTopPaths:  20,
         },
         Safety: SafetyConfig{
-            ReadOnly:       true,
-            MaxFileSize:    10 * 1024 * 1024 * 1024, // 10GB
-            SkipSystemDirs: true,
-            MaxMemoryMB:    1024,
-            MaxOpenFiles:   10000,
+            PermissionWarnings: true,
+            ScanTimeoutMinutes: 60,
+        },
+        Daemon: DaemonConfig{
+            Listen:          "unix:/run/shuruhoja.sock",
+            IntervalMinutes: 360,
+            Roots:           []string{"/"},
+            StateFile:       "/var/lib/shuruhoja/latest.json",
+        },
+        Snapshot: SnapshotConfig{
+            Enabled: false,
+            Dir:     "/var/lib/shuruhoja/snapshots",
+            Keep:    30,
+        },
+        Docker: DockerConfig{
+            Enabled:    false,
+            Root:       "/var/lib/docker",
+            LogMaxSize: 1024 * 1024 * 1024, // 1GB
         },
     }
 }
 
+// loadFromFile applies every setting in the file at path to cfg. Problems
+// are collected rather than stopping at the first one, and returned as
+// Errors so that each can be reported with its line number.
 func loadFromFile(path string, cfg *Config) error {
     data, err := os.ReadFile(path)
     if err != nil {
         return err
     }
     
+    var errs Errors
+    fail := func(line int, format string, args ...interface{}) {
+        errs = append(errs, &ParseError{File: path, Line: line, Err: fmt.Errorf(format, args...)})
+    }
+    warn := func(line int, format string, args ...interface{}) {
+        cfg.warnings = append(cfg.warnings, &ParseError{File: path, Line: line, Err: fmt.Errorf(format, args...)})
+    }
+    
     lines := strings.Split(string(data), "\n")
     currentSection := ""
     
-    for _, line := range lines {
+    for i, line := range lines {
+        lineNo := i + 1
         line = strings.TrimSpace(line)
-        if line == "" || strings.HasPrefix(line, "#") {
+        if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
             continue
         }
         
         if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-            currentSection = line[1 : len(line)-1]
+            currentSection = strings.TrimSpace(line[1 : len(line)-1])
+            if !knownSection(currentSection) {
+                fail(lineNo, "unknown section [%s]", currentSection)
+            }
             continue
         }
         
         parts := strings.SplitN(line, "=", 2)
         if len(parts) != 2 {
+            fail(lineNo, "expected key = value, got %q", line)
             continue
         }
         
         key := strings.TrimSpace(parts[0])
         value := strings.TrimSpace(parts[1])
         
-        parseConfigValue(currentSection, key, value, cfg)
-    }
-    
-    return nil
-}
-
-func parseConfigValue(section, key, value string, cfg *Config) {
-    switch section {
-    case "general":
-        switch key {
-        case "max_workers":
-            if v, err := strconv.Atoi(value); err == nil {
-                cfg.General.MaxWorkers = v
-            }
-        case "skip_paths":
-            cfg.General.SkipPaths = strings.Split(value, ",")
-        case "max_depth":
-            if v, err := strconv.Atoi(value); err == nil {
-                cfg.General.MaxDepth = v
+        section := currentSection
+        if section == "" {
+            // Early configurations had no sections
+            name, ok := legacyName(key)
+            if !ok {
+                fail(lineNo, "key %q outside of any section", key)
+                continue
             }
+            warn(lineNo, "key %q outside of any section is deprecated, read as %s", key, name)
+            section, key, _ = strings.Cut(name, ".")
         }
-    case "detection":
-        switch key {
-        case "log_file_age_days":
-            if v, err := strconv.Atoi(value); err == nil {
-                cfg.Detection.LogFileAgeDays = v
-            }
-        case "orphan_dir_age_days":
-            if v, err := strconv.Atoi(value); err == nil {
-                cfg.Detection.OrphanDirAgeDays = v
+        if !knownSection(section) {
+            // Already reported at the section header
+            continue
+        }
+        
+        s := lookupSetting(section, key)
+        if s == nil {
+            if warning := deprecatedWarning(section + "." + key); warning != nil {
+                warn(lineNo, "%v", warning)
+            } else {
+                fail(lineNo, "unknown key %q in [%s]", key, section)
             }
+            continue
         }
-    // Add more cases for other sections
+        if err := s.set(cfg, value); err != nil {
+            fail(lineNo, "%s.%s: %v", section, key, err)
+            continue
+        }
+        cfg.setOrigin(s.name(), path)
+    }
+    
+    if len(errs) > 0 {
+        return errs
     }
+    return nil
 }
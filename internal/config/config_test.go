@@ -0,0 +1,378 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestParseSize(t *testing.T) {
+    const mb = 1024 * 1024
+    tests := []struct {
+        value   string
+        unit    int64
+        want    int64
+        wantErr bool
+    }{
+        {value: "100", unit: mb, want: 100 * mb},
+        {value: "0.5", unit: 1024 * mb, want: 512 * mb},
+        {value: "500M", unit: 1024 * mb, want: 500 * mb},
+        {value: "2GB", unit: mb, want: 2048 * mb},
+        {value: "1.5 GiB", unit: 1, want: 1536 * mb},
+        {value: "4k", unit: mb, want: 4096},
+        {value: "10B", unit: mb, want: 10},
+        {value: "1T", unit: 1, want: 1024 * 1024 * mb},
+        {value: "", unit: mb, wantErr: true},
+        {value: "abc", unit: mb, wantErr: true},
+        {value: "5X", unit: mb, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        got, err := parseSize(tt.value, tt.unit)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("parseSize(%q, %d) error = %v, want error %v", tt.value, tt.unit, err, tt.wantErr)
+            continue
+        }
+        if !tt.wantErr && got != tt.want {
+            t.Errorf("parseSize(%q, %d) = %d, want %d", tt.value, tt.unit, got, tt.want)
+        }
+    }
+}
+
+func TestLoadFromFile(t *testing.T) {
+    tests := []struct {
+        name    string
+        content string
+        // wantErrs and wantWarns are the lines and messages of the
+        // expected errors and warnings
+        wantErrs  []string
+        wantWarns []string
+        check     func(t *testing.T, cfg *Config)
+    }{
+        {
+            name: "values",
+            content: `# comment
+; another comment
+[general]
+max_workers = 8
+skip_paths = /proc, /sys ,,
+one_file_system = yes
+
+[detection]
+cache_min_size_mb = 2G
+node_modules_max_size_gb = 0.5
+duplicate_hash_method = SHA256
+`,
+            check: func(t *testing.T, cfg *Config) {
+                if cfg.General.MaxWorkers != 8 {
+                    t.Errorf("max_workers = %d, want 8", cfg.General.MaxWorkers)
+                }
+                if got := strings.Join(cfg.General.SkipPaths, ","); got != "/proc,/sys" {
+                    t.Errorf("skip_paths = %q, want /proc,/sys", got)
+                }
+                if !cfg.General.OneFileSystem {
+                    t.Error("one_file_system not set")
+                }
+                if cfg.Detection.CacheMinSize != 2<<30 {
+                    t.Errorf("cache_min_size_mb = %d, want %d", cfg.Detection.CacheMinSize, 2<<30)
+                }
+                if cfg.Detection.NodeModulesMaxSize != 512<<20 {
+                    t.Errorf("node_modules_max_size_gb = %d, want %d", cfg.Detection.NodeModulesMaxSize, 512<<20)
+                }
+                if cfg.Detection.DuplicateHashMethod != "sha256" {
+                    t.Errorf("duplicate_hash_method = %q, want sha256", cfg.Detection.DuplicateHashMethod)
+                }
+            },
+        },
+        {
+            name: "every error is reported",
+            content: `enabled = true
+[general]
+max_workers = 0
+max_depth = deep
+no equals sign
+[nowhere]
+key = value
+[output]
+format = xml
+color = maybe
+bogus = 1
+`,
+            wantErrs: []string{
+                `:1: key "enabled" outside of any section`,
+                `:3: general.max_workers: must be at least 1, got 0`,
+                `:4: general.max_depth: "deep" is not a whole number`,
+                `:5: expected key = value, got "no equals sign"`,
+                `:6: unknown section [nowhere]`,
+                `:9: output.format: "xml" is not one of`,
+                `:10: output.color: "maybe" is not a boolean`,
+                `:11: unknown key "bogus" in [output]`,
+            },
+        },
+        {
+            name: "deprecated keys",
+            content: `[safety]
+dry_run = true
+permission_warnings = false
+[logging]
+level = debug
+`,
+            wantWarns: []string{
+                `:2: safety.dry_run is deprecated and ignored`,
+                `:5: logging.level is deprecated and ignored`,
+            },
+            check: func(t *testing.T, cfg *Config) {
+                if cfg.Safety.PermissionWarnings {
+                    t.Error("permission_warnings not set next to a deprecated key")
+                }
+            },
+        },
+        {
+            name: "keys outside of any section",
+            content: `max_workers = 12
+output_format = json
+color_output = false
+cache_dir_min_size_mb = 50
+caution_size_gb = 1
+[output]
+max_results = 5
+`,
+            wantWarns: []string{
+                `:1: key "max_workers" outside of any section is deprecated, read as general.max_workers`,
+                `:2: key "output_format" outside of any section is deprecated, read as output.format`,
+                `:3: key "color_output" outside of any section is deprecated, read as output.color`,
+                `:4: key "cache_dir_min_size_mb" outside of any section is deprecated, read as detection.cache_min_size_mb`,
+                `:5: key "caution_size_gb" outside of any section is deprecated, read as risk_assessment.caution_size_gb`,
+                `:5: risk_assessment.caution_size_gb is deprecated and ignored`,
+            },
+            check: func(t *testing.T, cfg *Config) {
+                if cfg.General.MaxWorkers != 12 || cfg.Output.Format != "json" || cfg.Output.Color ||
+                    cfg.Detection.CacheMinSize != 50<<20 || cfg.Output.MaxResults != 5 {
+                    t.Errorf("legacy keys not applied: %+v %+v", cfg.General, cfg.Output)
+                }
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            path := filepath.Join(t.TempDir(), "shuruhoja.conf")
+            if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+                t.Fatal(err)
+            }
+
+            cfg := defaultConfig()
+            err := loadFromFile(path, cfg)
+            var errs Errors
+            if err != nil {
+                var ok bool
+                if errs, ok = err.(Errors); !ok {
+                    t.Fatalf("loadFromFile() error = %v, want Errors", err)
+                }
+            }
+            if len(errs) != len(tt.wantErrs) {
+                t.Fatalf("loadFromFile() = %d errors, want %d:\n%v", len(errs), len(tt.wantErrs), err)
+            }
+            for i, want := range tt.wantErrs {
+                if got := errs[i].Error(); !strings.HasPrefix(got, path) || !strings.Contains(got, want) {
+                    t.Errorf("error %d = %q, want %q", i, got, want)
+                }
+            }
+            warnings := cfg.Warnings()
+            if len(warnings) != len(tt.wantWarns) {
+                t.Fatalf("loadFromFile() = %d warnings, want %d:\n%v", len(warnings), len(tt.wantWarns), warnings)
+            }
+            for i, want := range tt.wantWarns {
+                if got := warnings[i].Error(); !strings.HasPrefix(got, path) || !strings.Contains(got, want) {
+                    t.Errorf("warning %d = %q, want %q", i, got, want)
+                }
+            }
+            if tt.check != nil {
+                tt.check(t, cfg)
+            }
+        })
+    }
+}
+
+func TestLoadFromEnv(t *testing.T) {
+    tests := []struct {
+        variable string
+        value    string
+        wantErr  string
+        wantWarn string
+        check    func(cfg *Config) bool
+    }{
+        {
+            variable: "SHURUHOJA_GENERAL_MAX_WORKERS",
+            value:    " 7 ",
+            check:    func(cfg *Config) bool { return cfg.General.MaxWorkers == 7 },
+        },
+        {
+            variable: "SHURUHOJA_SNAPSHOT_KEEP",
+            value:    "0",
+            check:    func(cfg *Config) bool { return cfg.Snapshot.Keep == 0 },
+        },
+        {variable: "SHURUHOJA_OUTPUT_SORT_BY", value: "name", wantErr: "output.sort_by:"},
+        {variable: "SHURUHOJA_OUTPUT_PROGRESS", value: "true", wantWarn: "output.progress is deprecated and ignored"},
+        {variable: "SHURUHOJA_NO_SUCH_KEY", value: "1", wantErr: "unknown setting"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.variable, func(t *testing.T) {
+            t.Setenv(tt.variable, tt.value)
+            cfg := defaultConfig()
+            errs := loadFromEnv(cfg)
+
+            if tt.wantErr != "" {
+                if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+                    t.Fatalf("loadFromEnv() = %v, want %q", errs, tt.wantErr)
+                }
+                return
+            }
+            if len(errs) != 0 {
+                t.Fatalf("loadFromEnv() = %v", errs)
+            }
+            if tt.wantWarn != "" {
+                if w := cfg.Warnings(); len(w) != 1 || !strings.Contains(w[0].Error(), tt.wantWarn) {
+                    t.Fatalf("warnings = %v, want %q", w, tt.wantWarn)
+                }
+                return
+            }
+            if !tt.check(cfg) {
+                t.Errorf("%s=%q not applied", tt.variable, tt.value)
+            }
+            name := lookupEnv(tt.variable).name()
+            if origin := cfg.origins[name]; origin != "env "+tt.variable {
+                t.Errorf("origin of %s = %q, want env %s", name, origin, tt.variable)
+            }
+        })
+    }
+}
+
+// Later layers override earlier ones, and each value remembers its layer.
+func TestLayers(t *testing.T) {
+    dir := t.TempDir()
+    system := filepath.Join(dir, "system.conf")
+    user := filepath.Join(dir, "user.conf")
+    os.WriteFile(system, []byte("[general]\nmax_workers = 4\nmax_depth = 3\n"), 0644)
+    os.WriteFile(user, []byte("[general]\nmax_workers = 16\n"), 0644)
+    t.Setenv("SHURUHOJA_GENERAL_MAX_DEPTH", "5")
+
+    cfg := defaultConfig()
+    for _, layer := range []string{system, user} {
+        if err := loadFromFile(layer, cfg); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if errs := loadFromEnv(cfg); len(errs) > 0 {
+        t.Fatal(errs)
+    }
+    if err := cfg.Set("output.max_results", "10", "flag --limit"); err != nil {
+        t.Fatal(err)
+    }
+
+    want := map[string][2]string{
+        "general.max_workers": {"16", user},
+        "general.max_depth":   {"5", "env SHURUHOJA_GENERAL_MAX_DEPTH"},
+        "output.max_results":  {"10", "flag --limit"},
+        "output.format":       {"table", "default"},
+    }
+    for _, v := range cfg.Values() {
+        if w, ok := want[v.Name]; ok && (v.Value != w[0] || v.Origin != w[1]) {
+            t.Errorf("%s = %q from %q, want %q from %q", v.Name, v.Value, v.Origin, w[0], w[1])
+        }
+    }
+}
+
+func TestSet(t *testing.T) {
+    tests := []struct {
+        name    string
+        value   string
+        wantErr string
+    }{
+        {name: "general.quick", value: "on"},
+        {name: "docker.log_max_size_gb", value: "512M"},
+        {name: "general.quick", value: "sometimes", wantErr: "not a boolean"},
+        {name: "general.follow_symlinks", value: "true"},
+        {name: "general.nothing", value: "1", wantErr: "unknown setting"},
+        {name: "nosection", value: "1", wantErr: "unknown setting"},
+    }
+
+    for _, tt := range tests {
+        err := defaultConfig().Set(tt.name, tt.value, "test")
+        switch {
+        case tt.wantErr == "" && err != nil:
+            t.Errorf("Set(%q, %q) = %v", tt.name, tt.value, err)
+        case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+            t.Errorf("Set(%q, %q) = %v, want %q", tt.name, tt.value, err, tt.wantErr)
+        }
+    }
+}
+
+// What config show prints for a setting can be read back unchanged.
+func TestValuesRoundTrip(t *testing.T) {
+    cfg := defaultConfig()
+    for _, v := range cfg.Values() {
+        again := defaultConfig()
+        if err := again.Set(v.Name, v.Value, "test"); err != nil {
+            t.Errorf("Set(%q, %q) = %v", v.Name, v.Value, err)
+            continue
+        }
+        if got := lookupName(v.Name).get(again); got != v.Value {
+            t.Errorf("%s = %q after reading back %q", v.Name, got, v.Value)
+        }
+    }
+}
+
+// Deprecated keys belong to sections a configuration file may still name.
+func TestDeprecatedSections(t *testing.T) {
+    for name := range deprecated {
+        if lookupName(name) != nil {
+            t.Errorf("%s is both a setting and deprecated", name)
+        }
+        section, _, _ := strings.Cut(name, ".")
+        if !knownSection(section) {
+            t.Errorf("section of %s is unknown", name)
+        }
+    }
+}
+
+// The configurations shipped by earlier releases still load, with a
+// warning for each key that is deprecated or outside of any section.
+func TestLegacyFiles(t *testing.T) {
+    tests := []struct {
+        file      string
+        wantWarns int
+        check     func(cfg *Config) bool
+    }{
+        {
+            file:      "baseline.conf",
+            wantWarns: 17,
+            check:     func(cfg *Config) bool { return cfg.General.MaxWorkers == 100 && cfg.Safety.ScanTimeoutMinutes == 60 },
+        },
+        {
+            file:      "install-sample.conf",
+            wantWarns: 13,
+            check: func(cfg *Config) bool {
+                return cfg.Output.MaxResults == 50 && cfg.Detection.CacheMinSize == 100<<20 &&
+                    strings.Join(cfg.General.SkipPaths, ",") == "/proc,/sys,/dev,/run"
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.file, func(t *testing.T) {
+            cfg := defaultConfig()
+            if err := loadFromFile(filepath.Join("testdata", tt.file), cfg); err != nil {
+                t.Fatalf("loadFromFile() error = %v", err)
+            }
+            if n := len(cfg.Warnings()); n != tt.wantWarns {
+                t.Errorf("%d warnings, want %d:\n%v", n, tt.wantWarns, cfg.Warnings())
+            }
+            if !tt.check(cfg) {
+                t.Error("settings not applied")
+            }
+        })
+    }
+}
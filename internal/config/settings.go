@@ -0,0 +1,363 @@
+package config
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// ParseError reports a problem with one line of a configuration file.
+type ParseError struct {
+    File string
+    Line int
+    Err  error
+}
+
+func (e *ParseError) Error() string {
+    if e.Line == 0 {
+        return fmt.Sprintf("%s: %v", e.File, e.Err)
+    }
+    return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+    return e.Err
+}
+
+// Errors collects every problem found while loading configuration.
+type Errors []*ParseError
+
+func (e Errors) Error() string {
+    lines := make([]string, len(e))
+    for i, err := range e {
+        lines[i] = err.Error()
+    }
+    return strings.Join(lines, "\n")
+}
+
+// OutputFormats lists the values accepted for output.format.
+var OutputFormats = []string{"table", "json", "ndjson", "csv", "tsv", "html", "markdown", "prometheus"}
+
+// setting describes one documented configuration key and how to apply its
+// value to a Config and read it back.
+type setting struct {
+    section string
+    key     string
+    set     func(cfg *Config, value string) error
+    get     func(cfg *Config) string
+}
+
+func (s *setting) name() string {
+    return s.section + "." + s.key
+}
+
+var settings = []setting{
+    intSetting("general", "max_workers", 1, func(c *Config) *int { return &c.General.MaxWorkers }),
+    listSetting("general", "skip_paths", func(c *Config) *[]string { return &c.General.SkipPaths }),
+    intSetting("general", "max_depth", 0, func(c *Config) *int { return &c.General.MaxDepth }),
+    boolSetting("general", "one_file_system", func(c *Config) *bool { return &c.General.OneFileSystem }),
+    listSetting("general", "skip_fs_types", func(c *Config) *[]string { return &c.General.SkipFSTypes }),
+    boolSetting("general", "quick", func(c *Config) *bool { return &c.General.Quick }),
+
+    intSetting("detection", "log_file_age_days", 0, func(c *Config) *int { return &c.Detection.LogFileAgeDays }),
+    listSetting("detection", "log_file_patterns", func(c *Config) *[]string { return &c.Detection.LogFilePatterns }),
+    intSetting("detection", "temp_file_age_days", 0, func(c *Config) *int { return &c.Detection.TempFileAgeDays }),
+    listSetting("detection", "temp_dir_patterns", func(c *Config) *[]string { return &c.Detection.TempDirPatterns }),
+    listSetting("detection", "temp_file_patterns", func(c *Config) *[]string { return &c.Detection.TempFilePatterns }),
+    listSetting("detection", "cache_dir_patterns", func(c *Config) *[]string { return &c.Detection.CacheDirPatterns }),
+    sizeSetting("detection", "cache_min_size_mb", func(c *Config) *int64 { return &c.Detection.CacheMinSize }),
+    intSetting("detection", "orphan_dir_age_days", 0, func(c *Config) *int { return &c.Detection.OrphanDirAgeDays }),
+    sizeSetting("detection", "orphan_dir_min_size_gb", func(c *Config) *int64 { return &c.Detection.OrphanDirMinSize }),
+    boolSetting("detection", "orphan_access_check", func(c *Config) *bool { return &c.Detection.OrphanAccessCheck }),
+    sizeSetting("detection", "duplicate_min_size_mb", func(c *Config) *int64 { return &c.Detection.DuplicateMinSize }),
+    sizeSetting("detection", "duplicate_sample_size", func(c *Config) *int64 { return &c.Detection.DuplicateSampleSize }),
+    enumSetting("detection", "duplicate_hash_method", []string{"md5", "sha1", "sha256"}, func(c *Config) *string { return &c.Detection.DuplicateHashMethod }),
+    sizeSetting("detection", "node_modules_max_size_gb", func(c *Config) *int64 { return &c.Detection.NodeModulesMaxSize }),
+    intSetting("detection", "project_inactive_days", 0, func(c *Config) *int { return &c.Detection.ProjectInactiveDays }),
+    sizeSetting("detection", "python_venv_max_size_gb", func(c *Config) *int64 { return &c.Detection.PythonVenvMaxSize }),
+    sizeSetting("detection", "docker_cache_max_size_gb", func(c *Config) *int64 { return &c.Detection.DockerCacheMaxSize }),
+    sizeSetting("detection", "journal_log_max_size_gb", func(c *Config) *int64 { return &c.Detection.JournalLogMaxSize }),
+
+    sizeSetting("risk_assessment", "critical_size_gb", func(c *Config) *int64 { return &c.Risk.CriticalSize }),
+    intSetting("risk_assessment", "critical_age_days", 0, func(c *Config) *int { return &c.Risk.CriticalAgeDays }),
+
+    enumSetting("output", "format", OutputFormats, func(c *Config) *string { return &c.Output.Format }),
+    boolSetting("output", "color", func(c *Config) *bool { return &c.Output.Color }),
+    intSetting("output", "max_results", 0, func(c *Config) *int { return &c.Output.MaxResults }),
+    enumSetting("output", "sort_by", []string{"size", "age", "path"}, func(c *Config) *string { return &c.Output.SortBy }),
+    enumSetting("output", "sort_order", []string{"asc", "desc"}, func(c *Config) *string { return &c.Output.SortOrder }),
+    intSetting("output", "truncate_path_length", 0, func(c *Config) *int { return &c.Output.TruncatePathLength }),
+    boolSetting("output", "show_full_path", func(c *Config) *bool { return &c.Output.ShowFullPath }),
+    boolSetting("output", "show_summary", func(c *Config) *bool { return &c.Output.ShowSummary }),
+    boolSetting("output", "show_recommendations", func(c *Config) *bool { return &c.Output.ShowRecommendations }),
+    boolSetting("output", "show_statistics", func(c *Config) *bool { return &c.Output.ShowStatistics }),
+    boolSetting("output", "apparent_size", func(c *Config) *bool { return &c.Output.ApparentSize }),
+    intSetting("output", "prometheus_top_paths", 0, func(c *Config) *int { return &c.Output.PrometheusTopPaths }),
+
+    boolSetting("safety", "permission_warnings", func(c *Config) *bool { return &c.Safety.PermissionWarnings }),
+    intSetting("safety", "scan_timeout_minutes", 0, func(c *Config) *int { return &c.Safety.ScanTimeoutMinutes }),
+
+    stringSetting("daemon", "listen", func(c *Config) *string { return &c.Daemon.Listen }),
+    intSetting("daemon", "interval_minutes", 0, func(c *Config) *int { return &c.Daemon.IntervalMinutes }),
+    listSetting("daemon", "roots", func(c *Config) *[]string { return &c.Daemon.Roots }),
+    stringSetting("daemon", "state_file", func(c *Config) *string { return &c.Daemon.StateFile }),
+
+    boolSetting("snapshot", "enabled", func(c *Config) *bool { return &c.Snapshot.Enabled }),
+    stringSetting("snapshot", "dir", func(c *Config) *string { return &c.Snapshot.Dir }),
+    intSetting("snapshot", "keep", 0, func(c *Config) *int { return &c.Snapshot.Keep }),
+
+    boolSetting("docker", "enabled", func(c *Config) *bool { return &c.Docker.Enabled }),
+    stringSetting("docker", "root", func(c *Config) *string { return &c.Docker.Root }),
+    sizeSetting("docker", "log_max_size_gb", func(c *Config) *int64 { return &c.Docker.LogMaxSize }),
+}
+
+// deprecated lists the keys earlier configurations set that nothing
+// reads, with why. They are accepted with a warning, so that an existing
+// configuration keeps working but says what no longer has an effect.
+var deprecated = map[string]string{
+    "general.follow_symlinks":             "symbolic links are never followed",
+    "risk_assessment.caution_size_gb":     "risk levels are set by each detector",
+    "risk_assessment.caution_age_days":    "risk levels are set by each detector",
+    "risk_assessment.world_writable_risk": "file permissions are not assessed",
+    "risk_assessment.setuid_risk":         "file permissions are not assessed",
+    "output.progress":                     "there is no progress display",
+    "safety.read_only":                    "nothing is ever modified or deleted",
+    "safety.dry_run":                      "nothing is ever modified or deleted",
+    "safety.max_file_size_gb":             "files of every size are scanned",
+    "safety.skip_system_dirs":             "use general.skip_paths",
+    "safety.max_memory_mb":                "memory use is not limited",
+    "safety.max_open_files":               "use general.max_workers",
+    "logging.enabled":                     "there is no log file",
+    "logging.level":                       "there is no log file",
+    "logging.file":                        "there is no log file",
+    "logging.max_size_mb":                 "there is no log file",
+    "logging.retention_days":              "there is no log file",
+}
+
+// legacyKeys maps keys the first sample configuration set outside of any
+// section, under names of their own, to their settings.
+var legacyKeys = map[string]string{
+    "output_format":         "output.format",
+    "color_output":          "output.color",
+    "cache_dir_min_size_mb": "detection.cache_min_size_mb",
+}
+
+// deprecatedWarning returns the warning for setting the deprecated key
+// called name ("section.key"), or nil when it is not one.
+func deprecatedWarning(name string) error {
+    if why, ok := deprecated[name]; ok {
+        return fmt.Errorf("%s is deprecated and ignored: %s", name, why)
+    }
+    return nil
+}
+
+// legacyName returns the "section.key" name of a key set outside of any
+// section: a renamed key, or the one setting of that key.
+func legacyName(key string) (string, bool) {
+    if name, ok := legacyKeys[key]; ok {
+        return name, true
+    }
+    var names []string
+    for i := range settings {
+        if settings[i].key == key {
+            names = append(names, settings[i].name())
+        }
+    }
+    for name := range deprecated {
+        if strings.HasSuffix(name, "."+key) {
+            names = append(names, name)
+        }
+    }
+    if len(names) != 1 {
+        return "", false
+    }
+    return names[0], true
+}
+
+func knownSection(section string) bool {
+    for _, s := range settings {
+        if s.section == section {
+            return true
+        }
+    }
+    for name := range deprecated {
+        if strings.HasPrefix(name, section+".") {
+            return true
+        }
+    }
+    return false
+}
+
+// lookupName finds a setting by its "section.key" name.
+func lookupName(name string) *setting {
+    section, key, ok := strings.Cut(name, ".")
+    if !ok {
+        return nil
+    }
+    return lookupSetting(section, key)
+}
+
+// lookupEnv finds the setting for an environment variable such as
+// SHURUHOJA_GENERAL_MAX_WORKERS.
+func lookupEnv(variable string) *setting {
+    name := strings.ToLower(strings.TrimPrefix(variable, envPrefix))
+    for i := range settings {
+        if settings[i].section+"_"+settings[i].key == name {
+            return &settings[i]
+        }
+    }
+    return nil
+}
+
+// deprecatedEnv returns the deprecated key an environment variable sets.
+func deprecatedEnv(variable string) (string, bool) {
+    name := strings.ToLower(strings.TrimPrefix(variable, envPrefix))
+    for key := range deprecated {
+        if strings.Replace(key, ".", "_", 1) == name {
+            return key, true
+        }
+    }
+    return "", false
+}
+
+func lookupSetting(section, key string) *setting {
+    for i := range settings {
+        if settings[i].section == section && settings[i].key == key {
+            return &settings[i]
+        }
+    }
+    return nil
+}
+
+func intSetting(section, key string, min int, field func(*Config) *int) setting {
+    return setting{section: section, key: key, set: func(cfg *Config, value string) error {
+        v, err := strconv.Atoi(value)
+        if err != nil {
+            return fmt.Errorf("%q is not a whole number", value)
+        }
+        if v < min {
+            return fmt.Errorf("must be at least %d, got %d", min, v)
+        }
+        *field(cfg) = v
+        return nil
+    }, get: func(cfg *Config) string {
+        return strconv.Itoa(*field(cfg))
+    }}
+}
+
+func boolSetting(section, key string, field func(*Config) *bool) setting {
+    return setting{section: section, key: key, set: func(cfg *Config, value string) error {
+        switch strings.ToLower(value) {
+        case "true", "yes", "on", "1":
+            *field(cfg) = true
+        case "false", "no", "off", "0":
+            *field(cfg) = false
+        default:
+            return fmt.Errorf("%q is not a boolean (use true or false)", value)
+        }
+        return nil
+    }, get: func(cfg *Config) string {
+        return strconv.FormatBool(*field(cfg))
+    }}
+}
+
+func stringSetting(section, key string, field func(*Config) *string) setting {
+    return setting{section: section, key: key, set: func(cfg *Config, value string) error {
+        *field(cfg) = value
+        return nil
+    }, get: func(cfg *Config) string {
+        return *field(cfg)
+    }}
+}
+
+// enumSetting accepts one of allowed, compared case-insensitively.
+func enumSetting(section, key string, allowed []string, field func(*Config) *string) setting {
+    return setting{section: section, key: key, set: func(cfg *Config, value string) error {
+        lower := strings.ToLower(value)
+        for _, a := range allowed {
+            if lower == a {
+                *field(cfg) = lower
+                return nil
+            }
+        }
+        return fmt.Errorf("%q is not one of %s", value, strings.Join(allowed, ", "))
+    }, get: func(cfg *Config) string {
+        return *field(cfg)
+    }}
+}
+
+func listSetting(section, key string, field func(*Config) *[]string) setting {
+    return setting{section: section, key: key, set: func(cfg *Config, value string) error {
+        list := []string{}
+        for _, item := range strings.Split(value, ",") {
+            if item = strings.TrimSpace(item); item != "" {
+                list = append(list, item)
+            }
+        }
+        *field(cfg) = list
+        return nil
+    }, get: func(cfg *Config) string {
+        return strings.Join(*field(cfg), ",")
+    }}
+}
+
+// sizeSetting stores a size in bytes. A bare number is taken in the unit
+// named by the key's suffix (_mb, _gb, otherwise bytes) and may be
+// fractional, as in node_modules_max_size_gb = 0.5. An explicit unit such
+// as 500M or 2GB overrides the suffix.
+func sizeSetting(section, key string, field func(*Config) *int64) setting {
+    unit := int64(1)
+    switch {
+    case strings.HasSuffix(key, "_kb"):
+        unit = 1024
+    case strings.HasSuffix(key, "_mb"):
+        unit = 1024 * 1024
+    case strings.HasSuffix(key, "_gb"):
+        unit = 1024 * 1024 * 1024
+    }
+
+    return setting{section: section, key: key, set: func(cfg *Config, value string) error {
+        v, err := parseSize(value, unit)
+        if err != nil {
+            return err
+        }
+        *field(cfg) = v
+        return nil
+    }, get: func(cfg *Config) string {
+        return strconv.FormatFloat(float64(*field(cfg))/float64(unit), 'f', -1, 64)
+    }}
+}
+
+func parseSize(value string, unit int64) (int64, error) {
+    upper := strings.ToUpper(strings.TrimSpace(value))
+
+    end := 0
+    for end < len(upper) && (upper[end] == '.' || (upper[end] >= '0' && upper[end] <= '9')) {
+        end++
+    }
+    number, suffix := upper[:end], strings.TrimSpace(upper[end:])
+
+    n, err := strconv.ParseFloat(number, 64)
+    if err != nil {
+        return 0, fmt.Errorf("%q is not a size", value)
+    }
+
+    switch strings.TrimSuffix(strings.TrimSuffix(suffix, "B"), "I") {
+    case "":
+        if suffix == "B" {
+            unit = 1
+        }
+    case "K":
+        unit = 1024
+    case "M":
+        unit = 1024 * 1024
+    case "G":
+        unit = 1024 * 1024 * 1024
+    case "T":
+        unit = 1024 * 1024 * 1024 * 1024
+    default:
+        return 0, fmt.Errorf("%q has an unknown unit %q", value, strings.TrimSpace(strings.TrimSpace(value)[end:]))
+    }
+
+    return int64(n * float64(unit)), nil
+}
@@ -0,0 +1,308 @@
+package containers
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// analyze attributes the measured directories to images, containers and
+// volumes, and reports what could be reclaimed.
+func (d *docker) analyze() (*types.ContainerStorage, []types.ScanResult) {
+    apparent := d.opts.ApparentSize
+    storage := &types.ContainerStorage{Engine: "docker", Root: d.opts.Root}
+    var findings []types.ScanResult
+
+    // Which overlay2 directories something still refers to
+    referenced := make(map[string]bool)
+    for _, cacheID := range d.layers {
+        referenced[cacheID] = true
+    }
+    for _, c := range d.containers {
+        for _, id := range c.layers {
+            referenced[id] = true
+        }
+    }
+
+    containersOf := make(map[string]int)
+    volumeUsers := make(map[string]int)
+    for _, c := range d.containers {
+        containersOf[c.imageID]++
+        for _, v := range c.volumes {
+            volumeUsers[v]++
+        }
+    }
+
+    // Layers are shared between images: count each once for the totals,
+    // and reclaimable when no image with a container uses it
+    imagesOf := make(map[string]int)
+    activeLayer := make(map[string]bool)
+    for _, img := range d.images {
+        for _, chainID := range img.chain {
+            imagesOf[chainID]++
+            if containersOf[img.id] > 0 {
+                activeLayer[chainID] = true
+            }
+        }
+    }
+    imageRow := types.StorageUsage{Type: "Images", Total: len(d.images)}
+    for chainID := range imagesOf {
+        size := d.dirs[d.layers[chainID]].bytes(apparent)
+        imageRow.Size += size
+        if !activeLayer[chainID] {
+            imageRow.Reclaimable += size
+        }
+    }
+
+    for _, img := range d.images {
+        u := types.ImageUsage{
+            ID:         img.id,
+            Tags:       img.tags,
+            Created:    img.created,
+            Layers:     len(img.chain),
+            Containers: containersOf[img.id],
+        }
+        for _, chainID := range img.chain {
+            size := d.dirs[d.layers[chainID]].bytes(apparent)
+            u.Size += size
+            if imagesOf[chainID] == 1 {
+                u.UniqueSize += size
+            }
+        }
+        if u.Containers > 0 {
+            imageRow.Active++
+        }
+        storage.Images = append(storage.Images, u)
+
+        if len(img.tags) == 0 && u.Containers == 0 {
+            findings = append(findings, d.danglingImage(img, imagesOf)...)
+        }
+    }
+
+    containerRow := types.StorageUsage{Type: "Containers", Total: len(d.containers)}
+    logRow := types.StorageUsage{Type: "Container Logs"}
+    for _, c := range d.containers {
+        var layer usage
+        for _, id := range c.layers {
+            u := d.dirs[id]
+            layer.size += u.size
+            layer.allocated += u.allocated
+            if u.modTime.After(layer.modTime) {
+                layer.modTime = u.modTime
+            }
+        }
+        logs := c.logUsage()
+        storage.Containers = append(storage.Containers, types.ContainerUsage{
+            ID:         c.id,
+            Name:       c.name,
+            Image:      c.image,
+            Running:    c.running,
+            FinishedAt: c.finished,
+            Size:       layer.bytes(apparent),
+            LogSize:    logs.bytes(apparent),
+        })
+
+        containerRow.Size += layer.bytes(apparent)
+        logRow.Size += logs.bytes(apparent)
+        if logs.size > 0 {
+            logRow.Total++
+        }
+        if c.running {
+            containerRow.Active++
+            if logs.size > 0 {
+                logRow.Active++
+            }
+            if d.opts.LogMaxSize > 0 && logs.size >= d.opts.LogMaxSize {
+                findings = append(findings, d.runawayLog(c, logs))
+            }
+            continue
+        }
+        containerRow.Reclaimable += layer.bytes(apparent)
+        logRow.Reclaimable += logs.bytes(apparent)
+        findings = append(findings, d.stoppedContainer(c, layer, logs))
+    }
+
+    volumeRow := types.StorageUsage{Type: "Local Volumes", Total: len(d.volumes)}
+    for name, u := range d.volumes {
+        storage.Volumes = append(storage.Volumes, types.VolumeUsage{
+            Name:       name,
+            Size:       u.bytes(apparent),
+            Containers: volumeUsers[name],
+        })
+        volumeRow.Size += u.bytes(apparent)
+        if volumeUsers[name] > 0 {
+            volumeRow.Active++
+            continue
+        }
+        volumeRow.Reclaimable += u.bytes(apparent)
+        if u.size > 0 {
+            findings = append(findings, d.unusedVolume(name, u))
+        }
+    }
+
+    // Layers nothing refers to are left by builds, or by layers whose
+    // removal was interrupted
+    var unreferenced []string
+    cacheRow := types.StorageUsage{Type: "Build Cache", Size: d.buildkit.bytes(apparent)}
+    for id, u := range d.dirs {
+        if !referenced[id] {
+            unreferenced = append(unreferenced, id)
+            cacheRow.Total++
+            cacheRow.Size += u.bytes(apparent)
+        }
+    }
+    cacheRow.Reclaimable = cacheRow.Size
+    sort.Strings(unreferenced)
+    for _, id := range unreferenced {
+        if u := d.dirs[id]; u.size >= minLayerSize {
+            findings = append(findings, d.danglingLayer(id, u, cacheRow.Size))
+        }
+    }
+
+    storage.Usage = []types.StorageUsage{imageRow, containerRow, volumeRow, cacheRow, logRow}
+    sort.Slice(storage.Images, func(i, j int) bool { return storage.Images[i].Size > storage.Images[j].Size })
+    sort.Slice(storage.Containers, func(i, j int) bool { return storage.Containers[i].Size > storage.Containers[j].Size })
+    sort.Slice(storage.Volumes, func(i, j int) bool { return storage.Volumes[i].Size > storage.Volumes[j].Size })
+    return storage, findings
+}
+
+// result makes a finding of a measured file or directory.
+func (d *docker) result(path string, isDir bool, u usage) types.ScanResult {
+    return types.ScanResult{
+        Info: types.FileInfo{
+            Path:      path,
+            Size:      u.size,
+            Allocated: u.allocated,
+            IsDir:     isDir,
+            ModTime:   u.modTime,
+        },
+        Type:           types.TypeDocker,
+        RiskLevel:      types.RiskCaution,
+        Recommendation: types.RecReview,
+        AgeDays:        ageDays(u.modTime),
+    }
+}
+
+// danglingImage reports the layers only an untagged image uses, one
+// finding per layer with its own size, since an image has no directory of
+// its own. They go with the image, never by deleting the directories.
+func (d *docker) danglingImage(img *image, imagesOf map[string]int) []types.ScanResult {
+    var unique []string
+    for _, chainID := range img.chain {
+        if imagesOf[chainID] == 1 && d.layers[chainID] != "" {
+            unique = append(unique, d.layers[chainID])
+        }
+    }
+
+    var findings []types.ScanResult
+    for _, id := range unique {
+        u := d.dirs[id]
+        if u.size == 0 {
+            continue
+        }
+        if u.modTime.IsZero() {
+            u.modTime = img.created
+        }
+        result := d.result(d.path("overlay2", id), true, u)
+        result.AgeDays = ageDays(img.created)
+        result.Reason = fmt.Sprintf("Layer of untagged image %s used by no container (%s); remove the image with docker image prune",
+            shortID(img.id), formatSize(u.bytes(d.opts.ApparentSize)))
+        findings = append(findings, result)
+    }
+    return findings
+}
+
+func (d *docker) stoppedContainer(c *container, layer, logs usage) types.ScanResult {
+    total := usage{
+        size:      layer.size + logs.size,
+        allocated: layer.allocated + logs.allocated,
+        modTime:   layer.modTime,
+    }
+    if logs.modTime.After(total.modTime) {
+        total.modTime = logs.modTime
+    }
+    result := d.result(d.path("containers", c.id), true, total)
+
+    since := "never started"
+    if !c.finished.IsZero() && c.finished.Year() > 1 {
+        result.AgeDays = ageDays(c.finished)
+        since = fmt.Sprintf("stopped %d days ago", result.AgeDays)
+    }
+    if d.opts.CriticalAgeDays > 0 && result.AgeDays > d.opts.CriticalAgeDays {
+        result.RiskLevel = types.RiskCritical
+    }
+    result.Reason = fmt.Sprintf("Container %s (%s) %s, %s written and %s of logs; remove with docker rm %s",
+        c.label(), c.image, since, formatSize(layer.bytes(d.opts.ApparentSize)),
+        formatSize(logs.bytes(d.opts.ApparentSize)), c.label())
+    return result
+}
+
+func (d *docker) runawayLog(c *container, logs usage) types.ScanResult {
+    result := d.result(c.logPath, false, logs)
+    result.AgeDays = 0
+    if d.opts.CriticalSize > 0 && logs.size >= d.opts.CriticalSize {
+        result.RiskLevel = types.RiskCritical
+    }
+    result.Reason = fmt.Sprintf("Log of running container %s grew to %s; truncate it and set the max-size log option of the json-file driver",
+        c.label(), formatSize(logs.size))
+    return result
+}
+
+func (d *docker) unusedVolume(name string, u usage) types.ScanResult {
+    result := d.result(d.path("volumes", name), true, u)
+    result.Reason = fmt.Sprintf("Volume %s mounted by no container (%s); its data is lost with docker volume rm %s",
+        shortID(name), formatSize(u.size), name)
+    return result
+}
+
+// danglingLayer reports a layer nothing refers to. Deleting it by hand
+// would corrupt Docker's store, so it is only ever for review.
+func (d *docker) danglingLayer(id string, u usage, cacheSize int64) types.ScanResult {
+    result := d.result(d.path("overlay2", id), true, u)
+    cache := formatSize(cacheSize) + " of build cache in all"
+    if d.opts.BuildCacheMaxSize > 0 && cacheSize >= d.opts.BuildCacheMaxSize {
+        cache += ", over " + formatSize(d.opts.BuildCacheMaxSize)
+    }
+    result.Reason = fmt.Sprintf("Layer used by no image or container, %s; reclaim with docker builder prune", cache)
+    return result
+}
+
+// label names a container by its name, or its short ID without one.
+func (c *container) label() string {
+    if c.name != "" {
+        return c.name
+    }
+    return shortID(c.id)
+}
+
+// shortID shortens an ID the way the docker CLI shows it.
+func shortID(id string) string {
+    id = strings.TrimPrefix(id, "sha256:")
+    if len(id) > 12 {
+        return id[:12]
+    }
+    return id
+}
+
+func ageDays(t time.Time) int {
+    if t.IsZero() {
+        return 0
+    }
+    return int(time.Since(t).Hours() / 24)
+}
+
+func formatSize(bytes int64) string {
+    const unit = 1024
+    if bytes < unit {
+        return fmt.Sprintf("%d B", bytes)
+    }
+    div, exp := int64(unit), 0
+    for n := bytes / unit; n >= unit; n /= unit {
+        div *= unit
+        exp++
+    }
+    return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,363 @@
+// Package containers reads the storage of container engines from their
+// files on disk, without talking to the engine.
+package containers
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+    "syscall"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// Options controls how Docker storage is judged.
+type Options struct {
+    Root         string
+    ApparentSize bool
+    // LogMaxSize is the size from which the log of a running container is
+    // reported
+    LogMaxSize int64
+    // BuildCacheMaxSize is the size of build cache worth pruning
+    BuildCacheMaxSize int64
+    CriticalSize      int64
+    CriticalAgeDays   int
+}
+
+// Tree looks up the totals of the directories a scan walked.
+type Tree interface {
+    Get(path string) (types.DirSummary, bool)
+}
+
+// Layers below minLayerSize are counted but not reported one by one.
+const minLayerSize = 10 * 1024 * 1024
+
+// usage is the measured size of a directory tree or file.
+type usage struct {
+    size      int64
+    allocated int64
+    modTime   time.Time
+}
+
+func (u usage) bytes(apparent bool) int64 {
+    if apparent {
+        return u.size
+    }
+    return u.allocated
+}
+
+type image struct {
+    id      string
+    tags    []string
+    created time.Time
+    chain   []string
+}
+
+type container struct {
+    id       string
+    name     string
+    image    string
+    imageID  string
+    running  bool
+    finished time.Time
+    logPath  string
+    volumes  []string
+    layers   []string // overlay2 directories of the writable layer
+}
+
+// docker holds what was read from one Docker root.
+type docker struct {
+    ctx        context.Context
+    opts       Options
+    tree       Tree
+    dirs       map[string]usage  // overlay2 directory -> size
+    layers     map[string]string // chain ID -> overlay2 directory
+    images     []*image
+    containers []*container
+    volumes    map[string]usage
+    buildkit   usage
+}
+
+// Docker reads the overlay2 storage under opts.Root and returns its usage
+// together with what in it could be cleaned up. Directory sizes are taken
+// from tree when the scan walked them, and measured otherwise.
+func Docker(ctx context.Context, opts Options, tree Tree) (*types.ContainerStorage, []types.ScanResult, error) {
+    d := &docker{
+        ctx:     ctx,
+        opts:    opts,
+        tree:    tree,
+        dirs:    make(map[string]usage),
+        layers:  make(map[string]string),
+        volumes: make(map[string]usage),
+    }
+    if err := d.read(); err != nil {
+        return nil, nil, err
+    }
+    storage, findings := d.analyze()
+    return storage, findings, nil
+}
+
+func (d *docker) path(parts ...string) string {
+    return filepath.Join(append([]string{d.opts.Root}, parts...)...)
+}
+
+func (d *docker) read() error {
+    if _, err := os.Stat(d.path("image", "overlay2")); err != nil {
+        return fmt.Errorf("%s: no overlay2 image store: %w", d.opts.Root, err)
+    }
+
+    entries, err := os.ReadDir(d.path("overlay2"))
+    if err != nil {
+        return err
+    }
+    for _, entry := range entries {
+        // "l" holds the short symlinks overlayfs mounts with
+        if entry.IsDir() && entry.Name() != "l" {
+            d.dirs[entry.Name()] = d.measure(d.path("overlay2", entry.Name()))
+        }
+    }
+
+    layerDB := d.path("image", "overlay2", "layerdb", "sha256")
+    entries, _ = os.ReadDir(layerDB)
+    for _, entry := range entries {
+        if cacheID := readString(filepath.Join(layerDB, entry.Name(), "cache-id")); cacheID != "" {
+            d.layers["sha256:"+entry.Name()] = cacheID
+        }
+    }
+
+    d.readImages()
+    d.readContainers()
+
+    entries, _ = os.ReadDir(d.path("volumes"))
+    for _, entry := range entries {
+        data := d.path("volumes", entry.Name(), "_data")
+        if entry.IsDir() && exists(data) {
+            d.volumes[entry.Name()] = d.measure(data)
+        }
+    }
+    d.buildkit = d.measure(d.path("buildkit"))
+    return d.ctx.Err()
+}
+
+// readImages reads the image configs and their tags from repositories.json.
+func (d *docker) readImages() {
+    tags := make(map[string][]string)
+    var repos struct {
+        Repositories map[string]map[string]string
+    }
+    if data, err := os.ReadFile(d.path("image", "overlay2", "repositories.json")); err == nil {
+        json.Unmarshal(data, &repos)
+    }
+    for _, refs := range repos.Repositories {
+        for ref, id := range refs {
+            // Digest references name the same image as its tags
+            if !strings.Contains(ref, "@") {
+                tags[id] = append(tags[id], ref)
+            }
+        }
+    }
+
+    content := d.path("image", "overlay2", "imagedb", "content", "sha256")
+    entries, _ := os.ReadDir(content)
+    for _, entry := range entries {
+        data, err := os.ReadFile(filepath.Join(content, entry.Name()))
+        if err != nil {
+            continue
+        }
+        var config struct {
+            Created time.Time
+            RootFS  struct {
+                DiffIDs []string `json:"diff_ids"`
+            } `json:"rootfs"`
+        }
+        if json.Unmarshal(data, &config) != nil {
+            continue
+        }
+        id := "sha256:" + entry.Name()
+        d.images = append(d.images, &image{
+            id:      id,
+            tags:    tags[id],
+            created: config.Created,
+            chain:   chainIDs(config.RootFS.DiffIDs),
+        })
+    }
+}
+
+// chainIDs turns the diff IDs of an image's layers into the chain IDs the
+// layer store is keyed by.
+func chainIDs(diffIDs []string) []string {
+    var chain []string
+    for i, diffID := range diffIDs {
+        if i == 0 {
+            chain = append(chain, diffID)
+            continue
+        }
+        sum := sha256.Sum256([]byte(chain[i-1] + " " + diffID))
+        chain = append(chain, "sha256:"+hex.EncodeToString(sum[:]))
+    }
+    return chain
+}
+
+// readContainers reads every container's config.v2.json and the layers
+// its writable filesystem is made of.
+func (d *docker) readContainers() {
+    entries, _ := os.ReadDir(d.path("containers"))
+    for _, entry := range entries {
+        data, err := os.ReadFile(d.path("containers", entry.Name(), "config.v2.json"))
+        if err != nil {
+            continue
+        }
+        var config struct {
+            ID     string
+            Name   string
+            Image  string
+            Config struct {
+                Image string
+            }
+            State struct {
+                Running    bool
+                FinishedAt time.Time
+            }
+            LogPath     string
+            MountPoints map[string]struct {
+                Name string
+                Type string
+            }
+        }
+        if json.Unmarshal(data, &config) != nil {
+            continue
+        }
+
+        c := &container{
+            id:       config.ID,
+            name:     strings.TrimPrefix(config.Name, "/"),
+            image:    config.Config.Image,
+            imageID:  config.Image,
+            running:  config.State.Running,
+            finished: config.State.FinishedAt,
+            logPath:  config.LogPath,
+        }
+        if c.logPath == "" {
+            c.logPath = d.path("containers", c.id, c.id+"-json.log")
+        }
+        for _, m := range config.MountPoints {
+            if m.Type == "volume" && m.Name != "" {
+                c.volumes = append(c.volumes, m.Name)
+            }
+        }
+
+        mounts := d.path("image", "overlay2", "layerdb", "mounts", c.id)
+        for _, name := range []string{"mount-id", "init-id"} {
+            if id := readString(filepath.Join(mounts, name)); id != "" {
+                c.layers = append(c.layers, id)
+            }
+        }
+        d.containers = append(d.containers, c)
+    }
+}
+
+// logUsage measures a container's log together with its rotated files.
+func (c *container) logUsage() usage {
+    var total usage
+    matches, _ := filepath.Glob(c.logPath + "*")
+    for _, path := range matches {
+        info, err := os.Lstat(path)
+        if err != nil || !info.Mode().IsRegular() {
+            continue
+        }
+        total.size += info.Size()
+        if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+            total.allocated += stat.Blocks * 512
+        }
+        if info.ModTime().After(total.modTime) {
+            total.modTime = info.ModTime()
+        }
+    }
+    return total
+}
+
+// measure returns the totals of the directory at path as the scan found
+// them, or walks it when the scan did not get there.
+func (d *docker) measure(path string) usage {
+    if d.tree != nil {
+        if s, ok := d.tree.Get(path); ok {
+            return usage{size: s.TotalSize, allocated: s.Allocated, modTime: s.NewestMod}
+        }
+    }
+    return measure(d.ctx, path)
+}
+
+// measure totals the files below path, counting hard-linked data once. It
+// stays on the device of path, so that it never enters the "merged"
+// overlay mount of a running container, and stops when ctx is done.
+func measure(ctx context.Context, path string) usage {
+    var u usage
+    root, err := os.Lstat(path)
+    if err != nil {
+        return u
+    }
+    var dev uint64
+    if stat, ok := root.Sys().(*syscall.Stat_t); ok {
+        dev = uint64(stat.Dev)
+    }
+
+    seen := make(map[uint64]bool)
+    filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+        if ctx.Err() != nil {
+            return fs.SkipAll
+        }
+        if err != nil {
+            if entry != nil && entry.IsDir() {
+                return fs.SkipDir
+            }
+            return nil
+        }
+        info, err := entry.Info()
+        if err != nil {
+            return nil
+        }
+        if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+            if uint64(stat.Dev) != dev {
+                if info.IsDir() {
+                    return fs.SkipDir
+                }
+                return nil
+            }
+            if stat.Nlink > 1 && !info.IsDir() {
+                if seen[stat.Ino] {
+                    return nil
+                }
+                seen[stat.Ino] = true
+            }
+            u.allocated += stat.Blocks * 512
+        }
+        if info.ModTime().After(u.modTime) {
+            u.modTime = info.ModTime()
+        }
+        if info.Mode().IsRegular() {
+            u.size += info.Size()
+        }
+        return nil
+    })
+    return u
+}
+
+func readString(path string) string {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return ""
+    }
+    return strings.TrimSpace(string(data))
+}
+
+func exists(path string) bool {
+    _, err := os.Stat(path)
+    return err == nil
+}
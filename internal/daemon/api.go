@@ -0,0 +1,165 @@
+package daemon
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "shuru-hoja/internal/ui"
+    "shuru-hoja/pkg/types"
+)
+
+// summaryResponse is the body of GET /summary.
+type summaryResponse struct {
+    SchemaVersion int                  `json:"schema_version"`
+    Scanning      bool                 `json:"scanning"`
+    LastError     string               `json:"last_error,omitempty"`
+    Scan          types.ScanInfo       `json:"scan"`
+    Summary       types.Summary        `json:"summary"`
+    Mounts        []types.MountSummary `json:"mounts"`
+}
+
+// findingsResponse is the body of GET /findings.
+type findingsResponse struct {
+    SchemaVersion int                `json:"schema_version"`
+    Total         int                `json:"total"`
+    Findings      []types.ScanResult `json:"findings"`
+}
+
+// Handler returns the HTTP API:
+//
+//	GET  /summary                     latest summary and scan metadata
+//	GET  /findings?type=&risk=&limit= latest findings, optionally filtered
+//	POST /scan                        start a scan
+//	GET  /metrics                     Prometheus metrics
+//
+// Scans started through the API outlive their request and run under ctx.
+func (s *Server) Handler(ctx context.Context) http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/summary", s.handleSummary)
+    mux.HandleFunc("/findings", s.handleFindings)
+    mux.HandleFunc("/scan", func(w http.ResponseWriter, r *http.Request) {
+        s.handleScan(ctx, w, r)
+    })
+    mux.HandleFunc("/metrics", s.handleMetrics)
+    return mux
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+    if !allowMethod(w, r, http.MethodGet) {
+        return
+    }
+
+    report, running, lastErr := s.status()
+    if report == nil {
+        writeError(w, http.StatusServiceUnavailable, "no scan has completed yet")
+        return
+    }
+
+    resp := summaryResponse{
+        SchemaVersion: types.SchemaVersion,
+        Scanning:      running,
+        Scan:          report.Scan,
+        Summary:       report.Summary,
+        Mounts:        report.Mounts,
+    }
+    if lastErr != nil {
+        resp.LastError = lastErr.Error()
+    }
+    writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request) {
+    if !allowMethod(w, r, http.MethodGet) {
+        return
+    }
+
+    query := r.URL.Query()
+    limit := 0
+    if v := query.Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 {
+            writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", v))
+            return
+        }
+        limit = n
+    }
+    fileType := query.Get("type")
+    risk := query.Get("risk")
+
+    report, _, _ := s.status()
+    if report == nil {
+        writeError(w, http.StatusServiceUnavailable, "no scan has completed yet")
+        return
+    }
+
+    resp := findingsResponse{SchemaVersion: types.SchemaVersion, Findings: []types.ScanResult{}}
+    for _, f := range report.Findings {
+        if fileType != "" && string(f.Type) != fileType {
+            continue
+        }
+        if risk != "" && !strings.EqualFold(string(f.RiskLevel), risk) {
+            continue
+        }
+        resp.Total++
+        if limit == 0 || len(resp.Findings) < limit {
+            resp.Findings = append(resp.Findings, f)
+        }
+    }
+    writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleScan(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+    if !allowMethod(w, r, http.MethodPost) {
+        return
+    }
+
+    if !s.Start(ctx) {
+        writeError(w, http.StatusConflict, "a scan is already running")
+        return
+    }
+    writeJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+    if !allowMethod(w, r, http.MethodGet) {
+        return
+    }
+
+    report, running, _ := s.status()
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+    scanning := 0
+    if running {
+        scanning = 1
+    }
+    fmt.Fprintf(w, "# HELP shuruhoja_scan_running Whether a scan is running.\n")
+    fmt.Fprintf(w, "# TYPE shuruhoja_scan_running gauge\n")
+    fmt.Fprintf(w, "shuruhoja_scan_running %d\n", scanning)
+
+    if report != nil {
+        ui.WritePrometheus(w, report, s.topPaths)
+    }
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+    if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+        return true
+    }
+    w.Header().Set("Allow", method)
+    writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+    return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+    writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,204 @@
+package daemon
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "shuru-hoja/internal/ui"
+    "shuru-hoja/pkg/types"
+)
+
+// ScanFunc runs one complete scan.
+type ScanFunc func(ctx context.Context) (*types.Report, error)
+
+// Server runs scans on a schedule or on request, never more than one at a
+// time, and keeps the report of the latest one.
+type Server struct {
+    scan      ScanFunc
+    interval  time.Duration
+    stateFile string
+    topPaths  int
+
+    mu       sync.Mutex
+    latest   *types.Report
+    lastErr  error
+    running  bool
+    finished time.Time
+    wg       sync.WaitGroup
+}
+
+// New creates a server. An interval of 0 only scans on request, and an
+// empty stateFile keeps the latest report in memory only.
+func New(scan ScanFunc, interval time.Duration, stateFile string, topPaths int) *Server {
+    return &Server{
+        scan:      scan,
+        interval:  interval,
+        stateFile: stateFile,
+        topPaths:  topPaths,
+    }
+}
+
+// Load restores the latest report from the state file, if there is one.
+func (s *Server) Load() error {
+    if s.stateFile == "" {
+        return nil
+    }
+
+    file, err := os.Open(s.stateFile)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    report, err := ui.ReadJSON(file)
+    if err != nil {
+        return fmt.Errorf("%s: %w", s.stateFile, err)
+    }
+
+    s.mu.Lock()
+    s.latest = report
+    s.finished = report.Scan.StartedAt.Add(report.Scan.Elapsed())
+    s.mu.Unlock()
+    return nil
+}
+
+// Run starts scans on the schedule until ctx is done, then waits for a
+// running scan to stop. A report older than the interval, or none at all,
+// is replaced right away.
+func (s *Server) Run(ctx context.Context) {
+    defer s.wg.Wait()
+
+    if s.interval <= 0 {
+        <-ctx.Done()
+        return
+    }
+
+    s.mu.Lock()
+    wait := s.interval - time.Since(s.finished)
+    s.mu.Unlock()
+    if wait < 0 {
+        wait = 0
+    }
+
+    timer := time.NewTimer(wait)
+    defer timer.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-timer.C:
+            if !s.Start(ctx) {
+                log.Printf("scheduled scan skipped: a scan is still running")
+            }
+            timer.Reset(s.interval)
+        }
+    }
+}
+
+// Start begins a scan in the background and reports whether it did; it
+// does nothing while another scan is running.
+func (s *Server) Start(ctx context.Context) bool {
+    s.mu.Lock()
+    if s.running {
+        s.mu.Unlock()
+        return false
+    }
+    s.running = true
+    s.wg.Add(1)
+    s.mu.Unlock()
+
+    go func() {
+        defer s.wg.Done()
+
+        log.Printf("scan started")
+        report, err := s.scan(ctx)
+
+        s.mu.Lock()
+        s.running = false
+        s.lastErr = err
+        if err == nil {
+            s.latest = report
+            s.finished = time.Now()
+        }
+        s.mu.Unlock()
+
+        if err != nil {
+            log.Printf("scan failed: %v", err)
+            return
+        }
+        log.Printf("scan finished in %.1f s with %d findings", report.Scan.Duration, len(report.Findings))
+
+        if err := s.save(report); err != nil {
+            log.Printf("failed to save report: %v", err)
+        }
+    }()
+    return true
+}
+
+// status returns the latest report, whether a scan is running and the
+// error of the last scan.
+func (s *Server) status() (*types.Report, bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.latest, s.running, s.lastErr
+}
+
+func (s *Server) save(report *types.Report) error {
+    if s.stateFile == "" {
+        return nil
+    }
+    if err := os.MkdirAll(filepath.Dir(s.stateFile), 0755); err != nil {
+        return err
+    }
+    return ui.WriteAtomic(s.stateFile, func(w io.Writer) error {
+        return ui.WriteJSON(w, report)
+    })
+}
+
+// Listen opens the API address, either "unix:PATH" or a "host:port" on a
+// loopback interface. The API has no authentication, so it is never
+// offered on other interfaces.
+func Listen(address string) (net.Listener, error) {
+    if path, ok := strings.CutPrefix(address, "unix:"); ok {
+        // A socket left over from an unclean shutdown blocks the address
+        if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+            os.Remove(path)
+        }
+        if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+            return nil, err
+        }
+        listener, err := net.Listen("unix", path)
+        if err != nil {
+            return nil, err
+        }
+        if err := os.Chmod(path, 0660); err != nil {
+            listener.Close()
+            return nil, err
+        }
+        return listener, nil
+    }
+
+    host, _, err := net.SplitHostPort(address)
+    if err != nil {
+        return nil, err
+    }
+    if host != "localhost" {
+        ip := net.ParseIP(host)
+        if ip == nil || !ip.IsLoopback() {
+            return nil, fmt.Errorf("listen address %s is not a loopback address", address)
+        }
+    }
+    return net.Listen("tcp", address)
+}
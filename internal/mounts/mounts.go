@@ -0,0 +1,205 @@
+package mounts
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+    "syscall"
+)
+
+// Mount is one entry of /proc/self/mountinfo.
+type Mount struct {
+    ID           int
+    ParentID     int
+    Major        uint32
+    Minor        uint32
+    Root         string
+    MountPoint   string
+    Options      []string
+    FSType       string
+    Source       string
+    SuperOptions []string
+}
+
+// HasOption reports whether the mount was made with the given per-mount
+// option, such as "noatime".
+func (m Mount) HasOption(option string) bool {
+    for _, o := range m.Options {
+        if o == option {
+            return true
+        }
+    }
+    return false
+}
+
+// AtimeReliable reports whether access times on this mount are updated on
+// every read. With noatime they are never updated, and with relatime only
+// about once a day or when the file has been modified since.
+func (m Mount) AtimeReliable() bool {
+    return !m.HasOption("noatime") && !m.HasOption("relatime")
+}
+
+// Table is the set of mounts visible to this process.
+type Table struct {
+    mounts []Mount
+}
+
+// Load reads the mount table of the current process.
+func Load() (*Table, error) {
+    file, err := os.Open("/proc/self/mountinfo")
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    return Parse(file)
+}
+
+// Parse reads a table in the format of /proc/self/mountinfo.
+func Parse(r io.Reader) (*Table, error) {
+    table := &Table{}
+
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    for line := 1; scanner.Scan(); line++ {
+        if strings.TrimSpace(scanner.Text()) == "" {
+            continue
+        }
+        mount, err := parseLine(scanner.Text())
+        if err != nil {
+            return nil, fmt.Errorf("mountinfo line %d: %w", line, err)
+        }
+        table.mounts = append(table.mounts, mount)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+
+    return table, nil
+}
+
+// Mounts returns all mounts in mount order.
+func (t *Table) Mounts() []Mount {
+    return append([]Mount(nil), t.mounts...)
+}
+
+// Lookup returns the mount a path lives on, that is the last mounted entry
+// whose mount point is the longest prefix of path.
+func (t *Table) Lookup(path string) (Mount, bool) {
+    best := -1
+    for i, m := range t.mounts {
+        if !under(path, m.MountPoint) {
+            continue
+        }
+        if best < 0 || len(m.MountPoint) >= len(t.mounts[best].MountPoint) {
+            best = i
+        }
+    }
+    if best < 0 {
+        return Mount{}, false
+    }
+    return t.mounts[best], true
+}
+
+// Usage is the capacity of a filesystem as reported by statfs.
+type Usage struct {
+    TotalBytes int64
+    UsedBytes  int64
+    FreeBytes  int64
+}
+
+// DiskUsage returns the capacity of the filesystem mounted at path. Free
+// space is what unprivileged users may still allocate, as in df.
+func DiskUsage(path string) (Usage, error) {
+    var st syscall.Statfs_t
+    if err := syscall.Statfs(path, &st); err != nil {
+        return Usage{}, err
+    }
+
+    bsize := int64(st.Bsize)
+    return Usage{
+        TotalBytes: int64(st.Blocks) * bsize,
+        UsedBytes:  int64(st.Blocks-st.Bfree) * bsize,
+        FreeBytes:  int64(st.Bavail) * bsize,
+    }, nil
+}
+
+func under(path, mountPoint string) bool {
+    if mountPoint == "/" || path == mountPoint {
+        return strings.HasPrefix(path, "/")
+    }
+    return strings.HasPrefix(path, mountPoint+"/")
+}
+
+// parseLine parses a line such as
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseLine(line string) (Mount, error) {
+    fields := strings.Fields(line)
+
+    sep := -1
+    for i, f := range fields {
+        if f == "-" && i >= 6 {
+            sep = i
+            break
+        }
+    }
+    if sep < 0 || len(fields) < sep+3 {
+        return Mount{}, fmt.Errorf("malformed entry %q", line)
+    }
+
+    var m Mount
+    var err error
+    if m.ID, err = strconv.Atoi(fields[0]); err != nil {
+        return Mount{}, fmt.Errorf("bad mount ID %q", fields[0])
+    }
+    if m.ParentID, err = strconv.Atoi(fields[1]); err != nil {
+        return Mount{}, fmt.Errorf("bad parent ID %q", fields[1])
+    }
+
+    major, minor, ok := strings.Cut(fields[2], ":")
+    if !ok {
+        return Mount{}, fmt.Errorf("bad device %q", fields[2])
+    }
+    maj, err1 := strconv.ParseUint(major, 10, 32)
+    min, err2 := strconv.ParseUint(minor, 10, 32)
+    if err1 != nil || err2 != nil {
+        return Mount{}, fmt.Errorf("bad device %q", fields[2])
+    }
+    m.Major, m.Minor = uint32(maj), uint32(min)
+
+    m.Root = unescape(fields[3])
+    m.MountPoint = unescape(fields[4])
+    m.Options = strings.Split(fields[5], ",")
+    m.FSType = fields[sep+1]
+    m.Source = unescape(fields[sep+2])
+    if len(fields) > sep+3 {
+        m.SuperOptions = strings.Split(fields[sep+3], ",")
+    }
+
+    return m, nil
+}
+
+// unescape decodes the octal escapes (\040 for a space and so on) used by
+// the kernel for whitespace and backslashes in paths.
+func unescape(s string) string {
+    if !strings.Contains(s, `\`) {
+        return s
+    }
+
+    var b strings.Builder
+    for i := 0; i < len(s); i++ {
+        if s[i] == '\\' && i+4 <= len(s) {
+            if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+                b.WriteByte(byte(v))
+                i += 3
+                continue
+            }
+        }
+        b.WriteByte(s[i])
+    }
+    return b.String()
+}
@@ -0,0 +1,172 @@
+package mounts
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestParseLine(t *testing.T) {
+    tests := []struct {
+        name    string
+        line    string
+        want    Mount
+        wantErr bool
+    }{
+        {
+            name: "with optional fields",
+            line: `36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 shared:7 - ext3 /dev/root rw,errors=continue`,
+            want: Mount{
+                ID: 36, ParentID: 35, Major: 98, Minor: 0,
+                Root: "/mnt1", MountPoint: "/mnt2",
+                Options:      []string{"rw", "noatime"},
+                FSType:       "ext3",
+                Source:       "/dev/root",
+                SuperOptions: []string{"rw", "errors=continue"},
+            },
+        },
+        {
+            name: "without optional fields",
+            line: `22 1 0:21 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw`,
+            want: Mount{
+                ID: 22, ParentID: 1, Major: 0, Minor: 21,
+                Root: "/", MountPoint: "/proc",
+                Options:      []string{"rw", "nosuid", "nodev", "noexec", "relatime"},
+                FSType:       "proc",
+                Source:       "proc",
+                SuperOptions: []string{"rw"},
+            },
+        },
+        {
+            name: "escaped paths",
+            line: `90 25 8:17 /my\040dir /media/usb\040disk\011tab rw - vfat /dev/sdb\134x rw`,
+            want: Mount{
+                ID: 90, ParentID: 25, Major: 8, Minor: 17,
+                Root: "/my dir", MountPoint: "/media/usb disk\ttab",
+                Options:      []string{"rw"},
+                FSType:       "vfat",
+                Source:       `/dev/sdb\x`,
+                SuperOptions: []string{"rw"},
+            },
+        },
+        {
+            name: "no super options",
+            line: `40 22 0:35 / /mnt rw - tmpfs none`,
+            want: Mount{
+                ID: 40, ParentID: 22, Major: 0, Minor: 35,
+                Root: "/", MountPoint: "/mnt",
+                Options: []string{"rw"},
+                FSType:  "tmpfs",
+                Source:  "none",
+            },
+        },
+        {name: "no separator", line: `36 35 98:0 /mnt1 /mnt2 rw ext3 /dev/root rw`, wantErr: true},
+        {name: "separator too early", line: `36 35 98:0 - /mnt2 rw ext3 /dev/root rw`, wantErr: true},
+        {name: "nothing after separator", line: `36 35 98:0 /mnt1 /mnt2 rw - ext3`, wantErr: true},
+        {name: "bad mount ID", line: `x 35 98:0 / / rw - ext3 /dev/root rw`, wantErr: true},
+        {name: "bad parent ID", line: `36 y 98:0 / / rw - ext3 /dev/root rw`, wantErr: true},
+        {name: "bad device", line: `36 35 98 / / rw - ext3 /dev/root rw`, wantErr: true},
+        {name: "bad minor", line: `36 35 98:z / / rw - ext3 /dev/root rw`, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := parseLine(tt.line)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("parseLine() error = %v, want error %v", err, tt.wantErr)
+            }
+            if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("parseLine() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestUnescape(t *testing.T) {
+    tests := []struct {
+        in, want string
+    }{
+        {`/plain`, `/plain`},
+        {`/a\040b`, `/a b`},
+        {`/a\011b\012c`, "/a\tb\nc"},
+        {`/back\134slash`, `/back\slash`},
+        {`/not\08octal`, `/not\08octal`},
+        {`/short\04`, `/short\04`},
+        {`/trailing\`, `/trailing\`},
+    }
+
+    for _, tt := range tests {
+        if got := unescape(tt.in); got != tt.want {
+            t.Errorf("unescape(%q) = %q, want %q", tt.in, got, tt.want)
+        }
+    }
+}
+
+const mountinfo = `1 0 8:1 / / rw,relatime - ext4 /dev/sda1 rw
+2 1 8:2 / /home rw,noatime - ext4 /dev/sda2 rw
+
+3 1 0:30 / /var/lib/docker/overlay2/abc/merged rw - overlay overlay rw
+4 2 8:3 / /home rw - xfs /dev/sda3 rw
+5 1 0:40 / /media/usb\040disk rw,strictatime - vfat /dev/sdb1 rw
+`
+
+func TestLookup(t *testing.T) {
+    table, err := Parse(strings.NewReader(mountinfo))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n := len(table.Mounts()); n != 5 {
+        t.Fatalf("Parse() = %d mounts, want 5", n)
+    }
+
+    tests := []struct {
+        path   string
+        wantID int
+        wantOK bool
+    }{
+        {path: "/", wantID: 1, wantOK: true},
+        {path: "/etc/passwd", wantID: 1, wantOK: true},
+        // The later mount over /home hides the earlier one
+        {path: "/home", wantID: 4, wantOK: true},
+        {path: "/home/ann/.cache", wantID: 4, wantOK: true},
+        {path: "/homework", wantID: 1, wantOK: true},
+        {path: "/var/lib/docker/overlay2/abc/merged/etc", wantID: 3, wantOK: true},
+        {path: "/var/lib/docker/overlay2/abc", wantID: 1, wantOK: true},
+        {path: "/media/usb disk/photo.jpg", wantID: 5, wantOK: true},
+        {path: "relative", wantOK: false},
+    }
+
+    for _, tt := range tests {
+        m, ok := table.Lookup(tt.path)
+        if ok != tt.wantOK || (ok && m.ID != tt.wantID) {
+            t.Errorf("Lookup(%q) = mount %d, %v, want %d, %v", tt.path, m.ID, ok, tt.wantID, tt.wantOK)
+        }
+    }
+}
+
+func TestParseError(t *testing.T) {
+    _, err := Parse(strings.NewReader(mountinfo + "6 1 broken\n"))
+    if err == nil || !strings.Contains(err.Error(), "mountinfo line 7") {
+        t.Errorf("Parse() error = %v, want one on line 7", err)
+    }
+}
+
+func TestAtimeReliable(t *testing.T) {
+    tests := []struct {
+        options string
+        want    bool
+    }{
+        {options: "rw,strictatime", want: true},
+        {options: "rw", want: true},
+        {options: "rw,relatime", want: false},
+        {options: "ro,noatime", want: false},
+        {options: "rw,nodiratime", want: true},
+    }
+
+    for _, tt := range tests {
+        m := Mount{Options: strings.Split(tt.options, ",")}
+        if got := m.AtimeReliable(); got != tt.want {
+            t.Errorf("AtimeReliable() with %s = %v, want %v", tt.options, got, tt.want)
+        }
+    }
+}
@@ -3,6 +3,7 @@ package safety
 import (
     "os"
     "path/filepath"
+    "strings"
     "syscall"
 )
 
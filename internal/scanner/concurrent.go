@@ -4,146 +4,286 @@ import (
     "context"
     "os"
     "path/filepath"
+    "strings"
     "sync"
     "sync/atomic"
     "syscall"
+    "time"
 
+    "shuru-hoja/internal/mounts"
     "shuru-hoja/pkg/types"
 )
 
+// Options control which parts of the filesystem a scan descends into.
+type Options struct {
+    MaxWorkers int
+    // SkipPaths are absolute paths, or base names matched anywhere.
+    SkipPaths []string
+    // OneFileSystem keeps the scan on the filesystem of each root, like
+    // du -x.
+    OneFileSystem bool
+    // SkipFSTypes are filesystem types (from Mounts) never descended into.
+    SkipFSTypes []string
+    // MaxDepth stops the walk that many levels below each root, 0 means
+    // unlimited. Directories at the limit are reported but not entered.
+    MaxDepth int
+    Mounts   *mounts.Table
+}
+
 type ConcurrentScanner struct {
     maxWorkers   int
+    opts         Options
     results      chan types.FileInfo
     errors       chan error
-    wg           sync.WaitGroup
     scannedFiles int64
     scannedDirs  int64
     totalSize    int64
+    tree         *Tree
 }
 
-func NewConcurrentScanner(maxWorkers int) *ConcurrentScanner {
+func NewConcurrentScanner(opts Options) *ConcurrentScanner {
+    maxWorkers := opts.MaxWorkers
     if maxWorkers <= 0 {
         maxWorkers = 100
     }
+    if opts.SkipPaths == nil {
+        opts.SkipPaths = []string{"/proc", "/sys", "/dev", "/run", ".snapshot", ".zfs"}
+    }
+    if opts.Mounts == nil {
+        // Without /proc the scan still works, it just cannot tell
+        // filesystem types apart
+        if table, err := mounts.Load(); err == nil {
+            opts.Mounts = table
+        }
+    }
     return &ConcurrentScanner{
         maxWorkers: maxWorkers,
+        opts:       opts,
         results:    make(chan types.FileInfo, 10000),
         errors:     make(chan error, 100),
+        tree:       NewTree(),
     }
 }
 
-func (s *ConcurrentScanner) Scan(ctx context.Context, root string) (<-chan types.FileInfo, <-chan error) {
+// Mounts returns the mount table the scanner works with, which may be nil.
+func (s *ConcurrentScanner) Mounts() *mounts.Table {
+    return s.opts.Mounts
+}
+
+// Tree returns the directory totals gathered by the scan. It is complete
+// once the channels returned by Scan have been drained.
+func (s *ConcurrentScanner) Tree() *Tree {
+    return s.tree
+}
+
+// Scan walks every root with a pool of MaxWorkers walkers taking
+// directories from a shared queue. Roots should not overlap, or files
+// below both are reported twice. A root that is not a directory is
+// reported as a file of its own.
+func (s *ConcurrentScanner) Scan(ctx context.Context, roots ...string) (<-chan types.FileInfo, <-chan error) {
     go func() {
         defer close(s.results)
         defer close(s.errors)
-        
-        semaphore := make(chan struct{}, s.maxWorkers)
-        var scanWg sync.WaitGroup
-        
-        s.walkDir(ctx, root, semaphore, &scanWg)
-        scanWg.Wait()
+
+        queue := newDirQueue()
+        for _, root := range roots {
+            info, err := os.Lstat(root)
+            if err != nil {
+                if !s.sendError(ctx, err) {
+                    return
+                }
+                continue
+            }
+            if !isDirRoot(root, info) {
+                atomic.AddInt64(&s.scannedFiles, 1)
+                atomic.AddInt64(&s.totalSize, info.Size())
+                select {
+                case s.results <- createFileInfo(root, info):
+                case <-ctx.Done():
+                    return
+                }
+                continue
+            }
+            s.tree.AddRoot(root, info)
+            queue.push(dirJob{path: root, dev: deviceOf(info)})
+        }
+
+        // Walkers waiting for work are woken when the scan is cancelled
+        stop := context.AfterFunc(ctx, queue.close)
+        defer stop()
+
+        var wg sync.WaitGroup
+        for i := 0; i < s.maxWorkers; i++ {
+            wg.Add(1)
+            go func() {
+                defer wg.Done()
+                for {
+                    job, ok := queue.pop()
+                    if !ok {
+                        return
+                    }
+                    s.walkDir(ctx, job, queue)
+                    queue.done()
+                }
+            }()
+        }
+        wg.Wait()
     }()
-    
+
     return s.results, s.errors
 }
 
-func (s *ConcurrentScanner) walkDir(ctx context.Context, path string, sem chan struct{}, wg *sync.WaitGroup) {
-    select {
-    case <-ctx.Done():
+// isDirRoot reports whether a root is a directory, or a symbolic link to
+// one, which is walked like the directory itself.
+func isDirRoot(root string, info os.FileInfo) bool {
+    if info.Mode()&os.ModeSymlink != 0 {
+        if target, err := os.Stat(root); err == nil {
+            return target.IsDir()
+        }
+    }
+    return info.IsDir()
+}
+
+// walkDir reports the entries of one directory and queues its
+// subdirectories.
+func (s *ConcurrentScanner) walkDir(ctx context.Context, job dirJob, queue *dirQueue) {
+    if ctx.Err() != nil {
         return
-    default:
     }
-    
-    sem <- struct{}{}
-    wg.Add(1)
-    
-    go func() {
-        defer func() {
-            <-sem
-            wg.Done()
-        }()
-        
-        entries, err := os.ReadDir(path)
-        if err != nil {
-            if os.IsPermission(err) {
-                s.errors <- &PermissionError{Path: path, Err: err}
-            }
+
+    entries, err := os.ReadDir(job.path)
+    if err != nil {
+        if os.IsPermission(err) {
+            s.sendError(ctx, &PermissionError{Path: job.path, Err: err})
+        }
+        return
+    }
+
+    for _, entry := range entries {
+        if ctx.Err() != nil {
             return
         }
-        
-        for _, entry := range entries {
-            select {
-            case <-ctx.Done():
+
+        fullPath := filepath.Join(job.path, entry.Name())
+
+        if s.shouldSkip(fullPath) {
+            continue
+        }
+
+        info, err := entry.Info()
+        if err != nil {
+            if !s.sendError(ctx, err) {
                 return
-            default:
-            }
-            
-            fullPath := filepath.Join(path, entry.Name())
-            
-            if s.shouldSkip(fullPath) {
-                continue
-            }
-            
-            info, err := entry.Info()
-            if err != nil {
-                s.errors <- err
-                continue
             }
-            
-            fileInfo := s.createFileInfo(fullPath, info)
-            
-            if info.IsDir() {
-                atomic.AddInt64(&s.scannedDirs, 1)
-                s.walkDir(ctx, fullPath, sem, wg)
-            } else {
-                atomic.AddInt64(&s.scannedFiles, 1)
-                atomic.AddInt64(&s.totalSize, info.Size())
-            }
-            
-            select {
-            case s.results <- fileInfo:
-            case <-ctx.Done():
-                return
+            continue
+        }
+
+        fileInfo := createFileInfo(fullPath, info)
+        if info.IsDir() && fileInfo.Device != job.dev && s.skipMount(fullPath) {
+            continue
+        }
+        s.tree.Add(fileInfo)
+
+        if info.IsDir() {
+            atomic.AddInt64(&s.scannedDirs, 1)
+            if s.opts.MaxDepth <= 0 || job.depth+1 < s.opts.MaxDepth {
+                queue.push(dirJob{path: fullPath, dev: fileInfo.Device, depth: job.depth + 1})
             }
+        } else {
+            atomic.AddInt64(&s.scannedFiles, 1)
+            atomic.AddInt64(&s.totalSize, info.Size())
         }
-    }()
+
+        select {
+        case s.results <- fileInfo:
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// sendError passes err on unless the scan is cancelled first, and reports
+// whether it did.
+func (s *ConcurrentScanner) sendError(ctx context.Context, err error) bool {
+    select {
+    case s.errors <- err:
+        return true
+    case <-ctx.Done():
+        return false
+    }
 }
 
 func (s *ConcurrentScanner) shouldSkip(path string) bool {
-    skipPaths := []string{
-        "/proc", "/sys", "/dev", "/run",
-        ".snapshot", ".zfs",
+    for _, skip := range s.opts.SkipPaths {
+        skip = strings.TrimSpace(skip)
+        if path == skip || (!strings.HasPrefix(skip, "/") && filepath.Base(path) == skip) {
+            return true
+        }
+    }
+    return false
+}
+
+// skipMount decides whether to descend into a directory on which another
+// filesystem is mounted.
+func (s *ConcurrentScanner) skipMount(path string) bool {
+    if s.opts.OneFileSystem {
+        return true
+    }
+    if s.opts.Mounts == nil || len(s.opts.SkipFSTypes) == 0 {
+        return false
     }
     
-    for _, skip := range skipPaths {
-        if path == skip {
+    mount, ok := s.opts.Mounts.Lookup(path)
+    if !ok {
+        return false
+    }
+    for _, fsType := range s.opts.SkipFSTypes {
+        if mount.FSType == strings.TrimSpace(fsType) {
             return true
         }
     }
     return false
 }
 
-func (s *ConcurrentScanner) createFileInfo(path string, info os.FileInfo) types.FileInfo {
+func deviceOf(info os.FileInfo) uint64 {
+    if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+        return uint64(stat.Dev)
+    }
+    return 0
+}
+
+func createFileInfo(path string, info os.FileInfo) types.FileInfo {
     sys := info.Sys()
     
     var uid, gid uint32
-    var inode uint64
+    var inode, nlink, dev uint64
+    var allocated int64
+    atime, ctime := info.ModTime(), info.ModTime()
     
     if stat, ok := sys.(*syscall.Stat_t); ok {
         uid = stat.Uid
         gid = stat.Gid
         inode = stat.Ino
+        nlink = uint64(stat.Nlink)
+        dev = uint64(stat.Dev)
+        allocated = stat.Blocks * 512
+        atime = time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+        ctime = time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))
     }
     
     return types.FileInfo{
         Path:       path,
         Size:       info.Size(),
+        Allocated:  allocated,
         IsDir:      info.IsDir(),
         Mode:       info.Mode(),
         ModTime:    info.ModTime(),
-        AccessTime: info.ModTime(),
+        AccessTime: atime,
+        ChangeTime: ctime,
         UID:        uid,
         GID:        gid,
+        HardLinks:  nlink,
         Inode:      inode,
+        Device:     dev,
     }
 }
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+    "sync"
+)
+
+// dirJob is a directory waiting to be read.
+type dirJob struct {
+    path  string
+    dev   uint64
+    depth int
+}
+
+// dirQueue holds the directories still to be read. It is taken from the
+// end, so the walk goes depth first and the queue stays short. pop blocks
+// while walkers may still add work, and the queue is finished once every
+// directory taken has been marked done.
+type dirQueue struct {
+    mu      sync.Mutex
+    cond    *sync.Cond
+    jobs    []dirJob
+    pending int
+    closed  bool
+}
+
+func newDirQueue() *dirQueue {
+    q := &dirQueue{}
+    q.cond = sync.NewCond(&q.mu)
+    return q
+}
+
+func (q *dirQueue) push(job dirJob) {
+    q.mu.Lock()
+    q.jobs = append(q.jobs, job)
+    q.pending++
+    q.mu.Unlock()
+    q.cond.Signal()
+}
+
+// pop takes the next directory, or returns false once there is no more
+// work or the queue was closed.
+func (q *dirQueue) pop() (dirJob, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    for len(q.jobs) == 0 && q.pending > 0 && !q.closed {
+        q.cond.Wait()
+    }
+    if len(q.jobs) == 0 || q.closed {
+        return dirJob{}, false
+    }
+    job := q.jobs[len(q.jobs)-1]
+    q.jobs = q.jobs[:len(q.jobs)-1]
+    return job, true
+}
+
+// done marks a directory taken with pop as read.
+func (q *dirQueue) done() {
+    q.mu.Lock()
+    q.pending--
+    finished := q.pending == 0
+    q.mu.Unlock()
+    if finished {
+        q.cond.Broadcast()
+    }
+}
+
+// close makes every pop return false, for a cancelled scan.
+func (q *dirQueue) close() {
+    q.mu.Lock()
+    q.closed = true
+    q.mu.Unlock()
+    q.cond.Broadcast()
+}
@@ -15,6 +15,10 @@ func (e *PermissionError) Error() string {
     return fmt.Sprintf("permission denied: %s: %v", e.Path, e.Err)
 }
 
+func (e *PermissionError) Unwrap() error {
+    return e.Err
+}
+
 type Scanner interface {
     Scan(root string) (<-chan types.FileInfo, <-chan error)
     GetStats() Stats
@@ -0,0 +1,203 @@
+package scanner
+
+import (
+    "os"
+    "path/filepath"
+    "sort"
+    "sync"
+
+    "shuru-hoja/pkg/types"
+)
+
+// Tree keeps a running summary of every directory seen by a scan, so that
+// detectors and the UI can ask for the true size of a subtree instead of
+// the size of the directory inode.
+type Tree struct {
+    mu       sync.Mutex
+    roots    []string
+    dirs     map[string]*types.DirSummary
+    children map[string][]string
+    linked   map[fileID]bool
+}
+
+// fileID identifies an inode across all scanned filesystems.
+type fileID struct {
+    dev   uint64
+    inode uint64
+}
+
+func NewTree() *Tree {
+    return &Tree{
+        dirs:     make(map[string]*types.DirSummary),
+        children: make(map[string][]string),
+        linked:   make(map[fileID]bool),
+    }
+}
+
+// AddRoot registers a scan root. Entries are only rolled up as far as the
+// root they were found under.
+func (t *Tree) AddRoot(root string, info os.FileInfo) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    root = filepath.Clean(root)
+    t.roots = append(t.roots, root)
+
+    summary := t.dir(root)
+    summary.Info = createFileInfo(root, info)
+    t.touch(summary, summary.Info)
+}
+
+// Add accounts a scanned entry to every directory above it. The data of a
+// hard-linked file is only counted for the first of its links to be seen.
+func (t *Tree) Add(info types.FileInfo) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    counted := true
+    if info.SharesData() {
+        id := fileID{info.Device, info.Inode}
+        counted = !t.linked[id]
+        t.linked[id] = true
+    }
+
+    if info.IsDir {
+        summary := t.dir(info.Path)
+        summary.Info = info
+        t.touch(summary, info)
+    }
+
+    depth := 0
+    for dir := filepath.Dir(info.Path); ; dir = filepath.Dir(dir) {
+        depth++
+        summary := t.dir(dir)
+        if info.IsDir {
+            summary.DirCount++
+        } else {
+            if counted {
+                summary.TotalSize += info.Size
+                summary.Allocated += info.Allocated
+            }
+            summary.FileCount++
+        }
+        if depth > summary.MaxDepth {
+            summary.MaxDepth = depth
+        }
+        t.touch(summary, info)
+
+        if t.isRoot(dir) || dir == "/" || dir == "." {
+            break
+        }
+    }
+}
+
+// Get returns the summary of a scanned directory.
+func (t *Tree) Get(path string) (types.DirSummary, bool) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    summary, ok := t.dirs[path]
+    if !ok {
+        return types.DirSummary{}, false
+    }
+    return *summary, true
+}
+
+// Roots returns the scan roots in the order they were added.
+func (t *Tree) Roots() []string {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    return append([]string(nil), t.roots...)
+}
+
+// Children returns the immediate subdirectories of path, largest first.
+func (t *Tree) Children(path string) []types.DirSummary {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    var children []types.DirSummary
+    for _, child := range t.children[path] {
+        children = append(children, *t.dirs[child])
+    }
+
+    sort.Slice(children, func(i, j int) bool {
+        return children[i].TotalSize > children[j].TotalSize
+    })
+    return children
+}
+
+// Outline returns the largest directories below every root, at most width
+// per directory and depth levels deep. Directories smaller than minShare of
+// their root are left out.
+func (t *Tree) Outline(depth, width int, minShare float64) []types.DirNode {
+    var nodes []types.DirNode
+    for _, root := range t.Roots() {
+        summary, ok := t.Get(root)
+        if !ok {
+            continue
+        }
+        minSize := int64(float64(summary.TotalSize) * minShare)
+        nodes = append(nodes, t.outline(summary, depth, width, minSize))
+    }
+    return nodes
+}
+
+func (t *Tree) outline(summary types.DirSummary, depth, width int, minSize int64) types.DirNode {
+    node := types.DirNode{
+        Path:      summary.Info.Path,
+        Size:      summary.TotalSize,
+        Allocated: summary.Allocated,
+        Files:     summary.FileCount,
+    }
+    if depth <= 0 {
+        return node
+    }
+
+    for _, child := range t.Children(summary.Info.Path) {
+        if len(node.Children) >= width || child.TotalSize < minSize {
+            break
+        }
+        node.Children = append(node.Children, t.outline(child, depth-1, width, minSize))
+    }
+    return node
+}
+
+// Len returns the number of directories in the tree.
+func (t *Tree) Len() int {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    return len(t.dirs)
+}
+
+func (t *Tree) dir(path string) *types.DirSummary {
+    summary, ok := t.dirs[path]
+    if !ok {
+        summary = &types.DirSummary{Info: types.FileInfo{Path: path, IsDir: true}}
+        t.dirs[path] = summary
+        if !t.isRoot(path) && path != "/" {
+            parent := filepath.Dir(path)
+            t.children[parent] = append(t.children[parent], path)
+        }
+    }
+    return summary
+}
+
+func (t *Tree) touch(summary *types.DirSummary, info types.FileInfo) {
+    if info.ModTime.After(summary.NewestMod) {
+        summary.NewestMod = info.ModTime
+    }
+    if info.AccessTime.After(summary.NewestAccess) {
+        summary.NewestAccess = info.AccessTime
+    }
+}
+
+func (t *Tree) isRoot(path string) bool {
+    for _, root := range t.roots {
+        if path == root {
+            return true
+        }
+    }
+    return false
+}
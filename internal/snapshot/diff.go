@@ -0,0 +1,148 @@
+package snapshot
+
+import (
+    "sort"
+
+    "shuru-hoja/pkg/types"
+)
+
+// Compare works out what changed from snapshot a to snapshot b. The lists
+// of paths hold at most limit entries each, all of them when limit is 0.
+// Sizes are apparent only when both scans recorded them that way.
+func Compare(a, b *Snapshot, limit int) *types.Diff {
+    apparent := a.ApparentSize && b.ApparentSize
+    diff := &types.Diff{
+        SchemaVersion: types.SchemaVersion,
+        From:          info(a),
+        To:            info(b),
+        ApparentSize:  apparent,
+        ScannedBefore: a.Summary.TotalScannedBytes,
+        ScannedAfter:  b.Summary.TotalScannedBytes,
+        Grown:         []types.PathDelta{},
+        NewFiles:      []types.PathDelta{},
+        Deleted:       []types.PathDelta{},
+    }
+
+    before := make(map[string]Entry, len(a.Entries))
+    for _, e := range a.Entries {
+        before[e.Path] = e
+    }
+
+    seen := make(map[string]bool, len(b.Entries))
+    for _, e := range b.Entries {
+        seen[e.Path] = true
+        after := e.Usage(apparent)
+
+        old, ok := before[e.Path]
+        switch {
+        case !ok && !e.IsDir:
+            diff.NewFiles = append(diff.NewFiles, delta(e, 0, after))
+        case !ok:
+            // A new directory grew from nothing
+            if after > 0 {
+                diff.Grown = append(diff.Grown, delta(e, 0, after))
+            }
+        case after > old.Usage(apparent):
+            diff.Grown = append(diff.Grown, delta(e, old.Usage(apparent), after))
+        }
+    }
+    for _, e := range a.Entries {
+        if !seen[e.Path] && !e.IsDir {
+            diff.Deleted = append(diff.Deleted, delta(e, e.Usage(apparent), 0))
+        }
+    }
+
+    diff.Grown = largest(diff.Grown, limit, func(p types.PathDelta) int64 { return p.Delta })
+    diff.NewFiles = largest(diff.NewFiles, limit, func(p types.PathDelta) int64 { return p.After })
+    diff.Deleted = largest(diff.Deleted, limit, func(p types.PathDelta) int64 { return p.Before })
+    diff.Types = typeDeltas(a, b, apparent)
+    diff.Mounts = mountDeltas(a, b)
+
+    return diff
+}
+
+func info(s *Snapshot) types.SnapshotInfo {
+    return types.SnapshotInfo{Host: s.Host, Roots: s.Roots, Taken: s.Taken}
+}
+
+func delta(e Entry, before, after int64) types.PathDelta {
+    return types.PathDelta{Path: e.Path, IsDir: e.IsDir, Before: before, After: after, Delta: after - before}
+}
+
+// largest sorts deltas by key, largest first, and keeps the first limit.
+func largest(deltas []types.PathDelta, limit int, key func(types.PathDelta) int64) []types.PathDelta {
+    sort.SliceStable(deltas, func(i, j int) bool {
+        if key(deltas[i]) != key(deltas[j]) {
+            return key(deltas[i]) > key(deltas[j])
+        }
+        return deltas[i].Path < deltas[j].Path
+    })
+    if limit > 0 && len(deltas) > limit {
+        deltas = deltas[:limit]
+    }
+    return deltas
+}
+
+func typeDeltas(a, b *Snapshot, apparent bool) []types.TypeDelta {
+    byType := make(map[types.FileType]*types.TypeDelta)
+    var order []types.FileType
+    get := func(t types.FileType) *types.TypeDelta {
+        d, ok := byType[t]
+        if !ok {
+            d = &types.TypeDelta{Type: t}
+            byType[t] = d
+            order = append(order, t)
+        }
+        return d
+    }
+
+    for _, e := range a.Entries {
+        if e.Type != "" {
+            d := get(e.Type)
+            d.BeforeCount++
+            d.Before += e.Usage(apparent)
+        }
+    }
+    for _, e := range b.Entries {
+        if e.Type != "" {
+            d := get(e.Type)
+            d.AfterCount++
+            d.After += e.Usage(apparent)
+        }
+    }
+
+    deltas := []types.TypeDelta{}
+    for _, t := range order {
+        d := byType[t]
+        d.Delta = d.After - d.Before
+        deltas = append(deltas, *d)
+    }
+    sort.SliceStable(deltas, func(i, j int) bool {
+        return deltas[i].Delta > deltas[j].Delta
+    })
+    return deltas
+}
+
+// mountDeltas compares the used space of filesystems seen by both scans.
+func mountDeltas(a, b *Snapshot) []types.MountDelta {
+    before := make(map[string]int64)
+    for _, m := range a.Mounts {
+        before[m.MountPoint] = m.UsedBytes
+    }
+
+    deltas := []types.MountDelta{}
+    for _, m := range b.Mounts {
+        used, ok := before[m.MountPoint]
+        if !ok {
+            continue
+        }
+        deltas = append(deltas, types.MountDelta{
+            MountPoint: m.MountPoint,
+            Before:     used,
+            After:      m.UsedBytes,
+            Delta:      m.UsedBytes - used,
+        })
+    }
+    sort.Slice(deltas, func(i, j int) bool { return deltas[i].MountPoint < deltas[j].MountPoint })
+    return deltas
+}
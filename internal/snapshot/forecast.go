@@ -0,0 +1,272 @@
+package snapshot
+
+import (
+    "math"
+    "sort"
+    "strings"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+// Directories this many levels below a scan root are candidates for
+// driving the growth of their filesystem; at most maxDrivers are named.
+const (
+    topDirDepth = 2
+    maxDrivers  = 5
+)
+
+// sample is what a forecast needs from one snapshot.
+type sample struct {
+    taken  time.Time
+    mounts map[string]int64
+    dirs   map[string]int64
+}
+
+// Forecast fits growth trends to the snapshots of report's host in the
+// store, together with the filesystem usage in report as the newest
+// reading, and projects when each filesystem of report fills up. Only
+// filesystems seen at least twice are forecast. Snapshots that cannot be
+// read are left out.
+func (s Store) Forecast(report *types.Report) ([]types.MountForecast, error) {
+    files, err := s.List()
+    if err != nil {
+        return nil, err
+    }
+
+    var samples []sample
+    for _, file := range files {
+        header, err := LoadHeader(file)
+        if err != nil {
+            continue
+        }
+        if header.Host == report.Scan.Host {
+            samples = append(samples, sampleOf(header))
+        }
+    }
+    // The report is already in the store when it was saved as a snapshot
+    if n := len(samples); n == 0 || !samples[n-1].taken.Equal(report.Scan.StartedAt) {
+        current := sample{taken: report.Scan.StartedAt, mounts: make(map[string]int64)}
+        for _, m := range report.Mounts {
+            current.mounts[m.MountPoint] = m.UsedBytes
+        }
+        samples = append(samples, current)
+    }
+    sort.SliceStable(samples, func(i, j int) bool { return samples[i].taken.Before(samples[j].taken) })
+
+    var forecasts []types.MountForecast
+    for _, m := range report.Mounts {
+        trend, ok := fit(samples, func(s sample) (int64, bool) {
+            used, ok := s.mounts[m.MountPoint]
+            return used, ok
+        })
+        if !ok {
+            continue
+        }
+
+        f := types.MountForecast{
+            MountPoint: m.MountPoint,
+            TotalBytes: m.TotalBytes,
+            UsedBytes:  m.UsedBytes,
+            FreeBytes:  m.FreeBytes,
+            Trend:      trend,
+        }
+        last := samples[len(samples)-1].taken
+        if days, ok := daysUntil(m.FreeBytes, trend.BytesPerDay); ok {
+            f.DaysLeft = &days
+            f.FullAt = after(last, days)
+        }
+        if trend.Samples > 2 {
+            if days, ok := daysUntil(m.FreeBytes, trend.HighPerDay); ok {
+                f.FullEarliest = after(last, days)
+            }
+            if days, ok := daysUntil(m.FreeBytes, trend.LowPerDay); ok {
+                f.FullLatest = after(last, days)
+            }
+        }
+        forecasts = append(forecasts, f)
+    }
+
+    for i := range forecasts {
+        forecasts[i].Drivers = drivers(samples, &forecasts[i], report.Mounts)
+    }
+    return forecasts, nil
+}
+
+func sampleOf(header *Header) sample {
+    s := sample{
+        taken:  header.Taken,
+        mounts: make(map[string]int64, len(header.Mounts)),
+        dirs:   header.TopDirs,
+    }
+    for _, m := range header.Mounts {
+        s.mounts[m.MountPoint] = m.UsedBytes
+    }
+    return s
+}
+
+// depthBelow returns how many levels path is below root, or -1.
+func depthBelow(root, path string) int {
+    if path == root {
+        return 0
+    }
+    prefix := root
+    if root != "/" {
+        prefix += "/"
+    }
+    if !strings.HasPrefix(path, prefix) {
+        return -1
+    }
+    return strings.Count(path[len(prefix):], "/") + 1
+}
+
+// drivers picks the directories on f's filesystem that grew fastest. A
+// directory is replaced by a subdirectory responsible for at least half
+// of its growth, so that the most specific cause is named.
+func drivers(samples []sample, f *types.MountForecast, mounts []types.MountSummary) []types.DirGrowth {
+    paths := make(map[string]bool)
+    for _, s := range samples {
+        for path := range s.dirs {
+            if mountOf(path, mounts) == f.MountPoint {
+                paths[path] = true
+            }
+        }
+    }
+
+    var candidates []types.DirGrowth
+    for path := range paths {
+        var size int64
+        trend, ok := fit(samples, func(s sample) (int64, bool) {
+            used, ok := s.dirs[path]
+            if ok {
+                size = used
+            }
+            return used, ok
+        })
+        if ok && trend.BytesPerDay > 0 {
+            candidates = append(candidates, types.DirGrowth{Path: path, Size: size, Trend: trend})
+        }
+    }
+    sort.Slice(candidates, func(i, j int) bool {
+        if candidates[i].Trend.BytesPerDay != candidates[j].Trend.BytesPerDay {
+            return candidates[i].Trend.BytesPerDay > candidates[j].Trend.BytesPerDay
+        }
+        return candidates[i].Path < candidates[j].Path
+    })
+
+    chosen := []types.DirGrowth{}
+next:
+    for _, c := range candidates {
+        for i, d := range chosen {
+            switch {
+            case depthBelow(d.Path, c.Path) > 0:
+                if c.Trend.BytesPerDay >= d.Trend.BytesPerDay/2 {
+                    chosen[i] = c
+                }
+                continue next
+            case depthBelow(c.Path, d.Path) > 0:
+                continue next
+            }
+        }
+        if len(chosen) < maxDrivers {
+            chosen = append(chosen, c)
+        }
+    }
+
+    for i := range chosen {
+        if f.Trend.BytesPerDay > 0 {
+            chosen[i].Share = math.Min(chosen[i].Trend.BytesPerDay/f.Trend.BytesPerDay, 1)
+        }
+    }
+    return chosen
+}
+
+// mountOf returns the mount point of the filesystem holding path.
+func mountOf(path string, mounts []types.MountSummary) string {
+    best := ""
+    for _, m := range mounts {
+        if depthBelow(m.MountPoint, path) >= 0 && len(m.MountPoint) > len(best) {
+            best = m.MountPoint
+        }
+    }
+    return best
+}
+
+// fit draws a least-squares line through the sizes value returns for the
+// samples, in bytes per day. It needs two samples at different times.
+func fit(samples []sample, value func(sample) (int64, bool)) (types.Trend, bool) {
+    var xs, ys []float64
+    var since time.Time
+    for _, s := range samples {
+        v, ok := value(s)
+        if !ok {
+            continue
+        }
+        if len(xs) == 0 {
+            since = s.taken
+        }
+        xs = append(xs, s.taken.Sub(since).Hours()/24)
+        ys = append(ys, float64(v))
+    }
+
+    n := float64(len(xs))
+    var meanX, meanY float64
+    for i := range xs {
+        meanX += xs[i] / n
+        meanY += ys[i] / n
+    }
+    var sxx, sxy float64
+    for i := range xs {
+        sxx += (xs[i] - meanX) * (xs[i] - meanX)
+        sxy += (xs[i] - meanX) * (ys[i] - meanY)
+    }
+    if len(xs) < 2 || sxx == 0 {
+        return types.Trend{}, false
+    }
+
+    slope := sxy / sxx
+    trend := types.Trend{
+        Samples:     len(xs),
+        Since:       since,
+        BytesPerDay: slope,
+        LowPerDay:   slope,
+        HighPerDay:  slope,
+    }
+    if len(xs) > 2 {
+        var ssr float64
+        for i := range xs {
+            r := ys[i] - (meanY + slope*(xs[i]-meanX))
+            ssr += r * r
+        }
+        margin := tValue(len(xs)-2) * math.Sqrt(ssr/(n-2)/sxx)
+        trend.LowPerDay = slope - margin
+        trend.HighPerDay = slope + margin
+    }
+    return trend, true
+}
+
+// tValue is the two-sided 95% quantile of Student's t distribution.
+func tValue(dof int) float64 {
+    table := []float64{12.71, 4.30, 3.18, 2.78, 2.57, 2.45, 2.36, 2.31, 2.26, 2.23}
+    if dof <= len(table) {
+        return table[dof-1]
+    }
+    return 1.96 + 2.5/float64(dof)
+}
+
+// daysUntil returns how long free bytes last at rate bytes per day.
+func daysUntil(free int64, rate float64) (float64, bool) {
+    if rate <= 0 {
+        return 0, false
+    }
+    return float64(free) / rate, true
+}
+
+func after(t time.Time, days float64) *time.Time {
+    // Beyond a century the date is meaningless and overflows time.Duration
+    if days > 36500 {
+        return nil
+    }
+    at := t.Add(time.Duration(days * 24 * float64(time.Hour)))
+    return &at
+}
@@ -0,0 +1,285 @@
+package snapshot
+
+import (
+    "math"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "shuru-hoja/pkg/types"
+)
+
+const gb = 1024 * 1024 * 1024
+
+var day0 = time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+
+func days(n float64) time.Time {
+    return day0.Add(time.Duration(n * 24 * float64(time.Hour)))
+}
+
+func near(got, want float64) bool {
+    return math.Abs(got-want) <= 1e-6*math.Max(1, math.Abs(want))
+}
+
+func TestFit(t *testing.T) {
+    tests := []struct {
+        name     string
+        samples  []sample
+        wantOK   bool
+        wantRate float64
+        wantN    int
+        // wantRange is set when the rate has a confidence range
+        wantRange bool
+    }{
+        {
+            name:     "two samples",
+            samples:  []sample{{taken: days(0), mounts: map[string]int64{"/": 100}}, {taken: days(2), mounts: map[string]int64{"/": 300}}},
+            wantOK:   true,
+            wantRate: 100,
+            wantN:    2,
+        },
+        {
+            name: "on a line",
+            samples: []sample{
+                {taken: days(0), mounts: map[string]int64{"/": 1000}},
+                {taken: days(1), mounts: map[string]int64{"/": 1500}},
+                {taken: days(3), mounts: map[string]int64{"/": 2500}},
+            },
+            wantOK:   true,
+            wantRate: 500,
+            wantN:    3,
+        },
+        {
+            name: "scattered",
+            samples: []sample{
+                {taken: days(0), mounts: map[string]int64{"/": 1000}},
+                {taken: days(1), mounts: map[string]int64{"/": 1300}},
+                {taken: days(2), mounts: map[string]int64{"/": 1100}},
+                {taken: days(3), mounts: map[string]int64{"/": 1600}},
+            },
+            wantOK:    true,
+            wantRate:  160,
+            wantN:     4,
+            wantRange: true,
+        },
+        {
+            name:     "shrinking",
+            samples:  []sample{{taken: days(0), mounts: map[string]int64{"/": 500}}, {taken: days(0.5), mounts: map[string]int64{"/": 400}}},
+            wantOK:   true,
+            wantRate: -200,
+            wantN:    2,
+        },
+        {
+            name: "missing readings are left out",
+            samples: []sample{
+                {taken: days(0), mounts: map[string]int64{"/home": 7}},
+                {taken: days(1), mounts: map[string]int64{"/": 100}},
+                {taken: days(2), mounts: map[string]int64{}},
+                {taken: days(5), mounts: map[string]int64{"/": 500}},
+            },
+            wantOK:   true,
+            wantRate: 100,
+            wantN:    2,
+        },
+        {
+            name:    "one sample",
+            samples: []sample{{taken: days(0), mounts: map[string]int64{"/": 100}}},
+        },
+        {
+            name:    "same time",
+            samples: []sample{{taken: days(1), mounts: map[string]int64{"/": 100}}, {taken: days(1), mounts: map[string]int64{"/": 200}}},
+        },
+        {
+            name: "no samples",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            trend, ok := fit(tt.samples, func(s sample) (int64, bool) {
+                used, ok := s.mounts["/"]
+                return used, ok
+            })
+            if ok != tt.wantOK {
+                t.Fatalf("fit() ok = %v, want %v", ok, tt.wantOK)
+            }
+            if !ok {
+                return
+            }
+            if !near(trend.BytesPerDay, tt.wantRate) {
+                t.Errorf("BytesPerDay = %v, want %v", trend.BytesPerDay, tt.wantRate)
+            }
+            if trend.Samples != tt.wantN {
+                t.Errorf("Samples = %d, want %d", trend.Samples, tt.wantN)
+            }
+            if trend.LowPerDay > trend.BytesPerDay || trend.HighPerDay < trend.BytesPerDay {
+                t.Errorf("range %v..%v does not hold %v", trend.LowPerDay, trend.HighPerDay, trend.BytesPerDay)
+            }
+            hasRange := !near(trend.LowPerDay, trend.BytesPerDay) || !near(trend.HighPerDay, trend.BytesPerDay)
+            if hasRange != tt.wantRange {
+                t.Errorf("range %v..%v around %v, want a range: %v", trend.LowPerDay, trend.HighPerDay, trend.BytesPerDay, tt.wantRange)
+            }
+        })
+    }
+}
+
+func TestDaysUntil(t *testing.T) {
+    tests := []struct {
+        free   int64
+        rate   float64
+        want   float64
+        wantOK bool
+    }{
+        {free: 10 * gb, rate: gb, want: 10, wantOK: true},
+        {free: gb, rate: 4 * gb, want: 0.25, wantOK: true},
+        {free: 0, rate: gb, want: 0, wantOK: true},
+        {free: 10 * gb, rate: 0},
+        {free: 10 * gb, rate: -gb},
+    }
+
+    for _, tt := range tests {
+        got, ok := daysUntil(tt.free, tt.rate)
+        if ok != tt.wantOK || (ok && !near(got, tt.want)) {
+            t.Errorf("daysUntil(%d, %v) = %v, %v, want %v, %v", tt.free, tt.rate, got, ok, tt.want, tt.wantOK)
+        }
+    }
+}
+
+func TestDepthBelow(t *testing.T) {
+    tests := []struct {
+        root, path string
+        want       int
+    }{
+        {"/", "/", 0},
+        {"/", "/var", 1},
+        {"/", "/var/lib", 2},
+        {"/home", "/home/ann/src", 2},
+        {"/home", "/home", 0},
+        {"/home", "/homework", -1},
+        {"/home", "/var", -1},
+    }
+
+    for _, tt := range tests {
+        if got := depthBelow(tt.root, tt.path); got != tt.want {
+            t.Errorf("depthBelow(%q, %q) = %d, want %d", tt.root, tt.path, got, tt.want)
+        }
+    }
+}
+
+func TestForecast(t *testing.T) {
+    store := Store{Dir: t.TempDir()}
+
+    // "/" grows by a gigabyte a day, most of it in /var/lib
+    for i := 0; i < 4; i++ {
+        n := int64(i)
+        snap := &Snapshot{Header: Header{
+            Version: formatVersion,
+            Host:    "web1",
+            Roots:   []string{"/"},
+            Taken:   days(float64(i)),
+            Mounts:  []types.MountSummary{{MountPoint: "/", TotalBytes: 100 * gb, UsedBytes: (40 + n) * gb}},
+            TopDirs: map[string]int64{
+                "/var":     10*gb + n*8*gb/10,
+                "/var/lib": 5*gb + n*7*gb/10,
+                "/home":    20*gb + n*gb/10,
+                "/srv":     3 * gb,
+            },
+        }}
+        if _, err := store.Save(snap); err != nil {
+            t.Fatal(err)
+        }
+    }
+    // Neither another host's snapshots nor unreadable ones count
+    other := &Snapshot{Header: Header{
+        Version: formatVersion,
+        Host:    "db1",
+        Taken:   days(1.5),
+        Mounts:  []types.MountSummary{{MountPoint: "/", UsedBytes: 99 * gb}},
+    }}
+    if _, err := store.Save(other); err != nil {
+        t.Fatal(err)
+    }
+    corrupt := filepath.Join(store.Dir, "snapshot-20260101-120000.000000000"+fileSuffix)
+    if err := os.WriteFile(corrupt, []byte("not gzip"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    report := &types.Report{
+        Scan: types.ScanInfo{Host: "web1", StartedAt: days(4)},
+        Mounts: []types.MountSummary{
+            {MountPoint: "/", TotalBytes: 100 * gb, UsedBytes: 44 * gb, FreeBytes: 56 * gb},
+            {MountPoint: "/data", TotalBytes: 10 * gb, UsedBytes: 5 * gb, FreeBytes: 5 * gb},
+        },
+    }
+    forecasts, err := store.Forecast(report)
+    if err != nil {
+        t.Fatalf("Forecast() error = %v", err)
+    }
+    if len(forecasts) != 1 {
+        t.Fatalf("Forecast() = %d forecasts, want only /, seen more than once", len(forecasts))
+    }
+
+    f := forecasts[0]
+    if f.MountPoint != "/" || f.Trend.Samples != 5 {
+        t.Errorf("forecast of %s from %d samples, want / from 5", f.MountPoint, f.Trend.Samples)
+    }
+    if !near(f.Trend.BytesPerDay, gb) {
+        t.Errorf("BytesPerDay = %v, want %v", f.Trend.BytesPerDay, float64(gb))
+    }
+    if f.DaysLeft == nil || !near(*f.DaysLeft, 56) {
+        t.Errorf("DaysLeft = %v, want 56", f.DaysLeft)
+    }
+    if f.FullAt == nil || f.FullAt.Sub(days(60)).Abs() > time.Second {
+        t.Errorf("FullAt = %v, want %v", f.FullAt, days(60))
+    }
+
+    // /var/lib takes the place of /var, which it is most of
+    want := []struct {
+        path  string
+        share float64
+    }{{"/var/lib", 0.7}, {"/home", 0.1}}
+    if len(f.Drivers) != len(want) {
+        t.Fatalf("Drivers = %+v, want %v", f.Drivers, want)
+    }
+    for i, w := range want {
+        d := f.Drivers[i]
+        if d.Path != w.path || math.Abs(d.Share-w.share) > 1e-3 {
+            t.Errorf("driver %d = %s with share %.3f, want %s with %.3f", i, d.Path, d.Share, w.path, w.share)
+        }
+    }
+}
+
+// A forecast reads the header alone, which Write puts ahead of the entries.
+func TestLoadHeader(t *testing.T) {
+    store := Store{Dir: t.TempDir()}
+    snap := &Snapshot{
+        Header: Header{
+            Version: formatVersion,
+            Host:    "web1",
+            Taken:   day0,
+            TopDirs: map[string]int64{"/var": gb},
+        },
+        Entries: []Entry{{Path: "/var", Allocated: gb, IsDir: true}, {Path: "/var/log/syslog", Size: 100}},
+    }
+    path, err := store.Save(snap)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    header, err := LoadHeader(path)
+    if err != nil {
+        t.Fatalf("LoadHeader() error = %v", err)
+    }
+    if header.Host != "web1" || !header.Taken.Equal(day0) || header.TopDirs["/var"] != gb {
+        t.Errorf("LoadHeader() = %+v", header)
+    }
+
+    loaded, err := Load(path)
+    if err != nil {
+        t.Fatalf("Load() error = %v", err)
+    }
+    if len(loaded.Entries) != 2 || loaded.Entries[1].Path != "/var/log/syslog" {
+        t.Errorf("Load() entries = %+v", loaded.Entries)
+    }
+}
@@ -0,0 +1,332 @@
+package snapshot
+
+import (
+    "compress/gzip"
+    "encoding/gob"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "shuru-hoja/internal/scanner"
+    "shuru-hoja/pkg/types"
+)
+
+// formatVersion is the version of the snapshot file layout.
+const formatVersion = 2
+
+// Entry is one scanned path. Type, Risk and Reason are set for findings
+// only.
+type Entry struct {
+    Path      string
+    Size      int64
+    Allocated int64
+    ModTime   int64 // Unix nanoseconds
+    Inode     uint64
+    IsDir     bool
+    Type      types.FileType
+    Risk      types.RiskLevel
+    Reason    string
+}
+
+// Header describes a snapshot. It is written ahead of the entries, so that
+// a forecast reads it without decoding them. TopDirs holds the disk usage
+// of the directories up to topDirDepth levels below the roots.
+type Header struct {
+    Version      int
+    Host         string
+    Roots        []string
+    Taken        time.Time
+    ApparentSize bool
+    Summary      types.Summary
+    Mounts       []types.MountSummary
+    TopDirs      map[string]int64
+}
+
+// Snapshot is the compact record of one scan kept to compare scans later.
+// Directory entries carry the size of their subtree.
+type Snapshot struct {
+    Header
+    Entries []Entry
+}
+
+// New records the results of a scan along with its report. Directory sizes
+// are taken from the scan's tree.
+func New(report *types.Report, results []types.ScanResult, tree *scanner.Tree) *Snapshot {
+    snap := &Snapshot{
+        Header: Header{
+            Version:      formatVersion,
+            Host:         report.Scan.Host,
+            Roots:        report.Scan.Roots,
+            Taken:        report.Scan.StartedAt,
+            ApparentSize: report.Scan.ApparentSize,
+            Summary:      report.Summary,
+            Mounts:       report.Mounts,
+            TopDirs:      make(map[string]int64),
+        },
+        Entries: make([]Entry, 0, len(results)),
+    }
+
+    for _, r := range results {
+        entry := Entry{
+            Path:      r.Info.Path,
+            Size:      r.Info.Size,
+            Allocated: r.Info.Allocated,
+            ModTime:   r.Info.ModTime.UnixNano(),
+            Inode:     r.Info.Inode,
+            IsDir:     r.Info.IsDir,
+        }
+        if r.Info.IsDir {
+            if summary, ok := tree.Get(r.Info.Path); ok {
+                entry.Size = summary.TotalSize
+                entry.Allocated = summary.Allocated
+            }
+        }
+        if r.IsFinding() {
+            entry.Type = r.Type
+            entry.Risk = r.RiskLevel
+            entry.Reason = r.Reason
+        }
+        snap.Entries = append(snap.Entries, entry)
+    }
+
+    // The roots themselves are not among the results
+    for _, root := range tree.Roots() {
+        if summary, ok := tree.Get(root); ok {
+            snap.Entries = append(snap.Entries, Entry{
+                Path:      root,
+                Size:      summary.TotalSize,
+                Allocated: summary.Allocated,
+                ModTime:   summary.Info.ModTime.UnixNano(),
+                Inode:     summary.Info.Inode,
+                IsDir:     true,
+            })
+        }
+    }
+
+    // Disk usage is compared with the filesystem's, never apparent sizes
+    for _, e := range snap.Entries {
+        if !e.IsDir {
+            continue
+        }
+        for _, root := range snap.Roots {
+            if d := depthBelow(root, e.Path); d > 0 && d <= topDirDepth {
+                snap.TopDirs[e.Path] = e.Allocated
+                break
+            }
+        }
+    }
+    return snap
+}
+
+// Usage returns the entry's disk usage, or its apparent size.
+func (e Entry) Usage(apparent bool) int64 {
+    if apparent {
+        return e.Size
+    }
+    return e.Allocated
+}
+
+// Write encodes a snapshot as gzip-compressed gob, its header first.
+func Write(w io.Writer, snap *Snapshot) error {
+    zw := gzip.NewWriter(w)
+    enc := gob.NewEncoder(zw)
+    if err := enc.Encode(snap.Header); err != nil {
+        zw.Close()
+        return err
+    }
+    if err := enc.Encode(snap.Entries); err != nil {
+        zw.Close()
+        return err
+    }
+    return zw.Close()
+}
+
+// Read decodes a snapshot written by Write.
+func Read(r io.Reader) (*Snapshot, error) {
+    zr, err := gzip.NewReader(r)
+    if err != nil {
+        return nil, fmt.Errorf("not a snapshot: %w", err)
+    }
+    defer zr.Close()
+
+    var snap Snapshot
+    dec := gob.NewDecoder(zr)
+    if err := readHeader(dec, &snap.Header); err != nil {
+        return nil, err
+    }
+    if err := dec.Decode(&snap.Entries); err != nil {
+        return nil, fmt.Errorf("not a snapshot: %w", err)
+    }
+    return &snap, nil
+}
+
+func readHeader(dec *gob.Decoder, header *Header) error {
+    if err := dec.Decode(header); err != nil {
+        return fmt.Errorf("not a snapshot: %w", err)
+    }
+    if header.Version != formatVersion {
+        return fmt.Errorf("unsupported snapshot version %d", header.Version)
+    }
+    return nil
+}
+
+// Load reads a snapshot file.
+func Load(path string) (*Snapshot, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    snap, err := Read(file)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    return snap, nil
+}
+
+// LoadHeader reads only the header of a snapshot file.
+func LoadHeader(path string) (*Header, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    zr, err := gzip.NewReader(file)
+    if err != nil {
+        return nil, fmt.Errorf("%s: not a snapshot: %w", path, err)
+    }
+    defer zr.Close()
+
+    var header Header
+    if err := readHeader(gob.NewDecoder(zr), &header); err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    return &header, nil
+}
+
+// Store is a directory holding one snapshot file per scan, named after the
+// time of the scan so that they sort oldest first.
+type Store struct {
+    Dir string
+    // Keep is the number of snapshots kept, 0 keeps all of them
+    Keep int
+}
+
+const fileSuffix = ".snap"
+
+// Save adds a snapshot to the store, removes the oldest ones beyond Keep
+// and returns the new file's path.
+func (s Store) Save(snap *Snapshot) (string, error) {
+    if err := os.MkdirAll(s.Dir, 0755); err != nil {
+        return "", err
+    }
+
+    path, err := s.create(snap)
+    if err != nil {
+        return "", err
+    }
+
+    if s.Keep > 0 {
+        files, err := s.List()
+        if err != nil {
+            return path, err
+        }
+        for len(files) > s.Keep {
+            if err := os.Remove(files[0]); err != nil {
+                return path, err
+            }
+            files = files[1:]
+        }
+    }
+    return path, nil
+}
+
+// create writes snap to a new file named after the time it was taken. The
+// file is written aside and linked into place, which fails rather than
+// replace an existing snapshot; a name already taken moves on by a
+// nanosecond, so that the names keep sorting in the order of the scans.
+func (s Store) create(snap *Snapshot) (string, error) {
+    tmp, err := os.CreateTemp(s.Dir, ".snapshot-*")
+    if err != nil {
+        return "", err
+    }
+    defer os.Remove(tmp.Name())
+
+    if err := Write(tmp, snap); err != nil {
+        tmp.Close()
+        return "", err
+    }
+    if err := tmp.Chmod(0644); err != nil {
+        tmp.Close()
+        return "", err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return "", err
+    }
+    if err := tmp.Close(); err != nil {
+        return "", err
+    }
+
+    taken := snap.Taken.UTC()
+    for {
+        path := filepath.Join(s.Dir, "snapshot-"+taken.Format("20060102-150405.000000000")+fileSuffix)
+        err := os.Link(tmp.Name(), path)
+        if err == nil {
+            return path, nil
+        }
+        if !os.IsExist(err) {
+            return "", err
+        }
+        taken = taken.Add(time.Nanosecond)
+    }
+}
+
+// List returns the snapshot files in the store, oldest first.
+func (s Store) List() ([]string, error) {
+    entries, err := os.ReadDir(s.Dir)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    var files []string
+    for _, entry := range entries {
+        name := entry.Name()
+        if entry.Type().IsRegular() && strings.HasPrefix(name, "snapshot-") && strings.HasSuffix(name, fileSuffix) {
+            files = append(files, filepath.Join(s.Dir, name))
+        }
+    }
+    sort.Strings(files)
+    return files, nil
+}
+
+// Resolve turns "latest" or "previous" into the newest or second newest
+// snapshot in the store. Anything else is taken as a file name.
+func (s Store) Resolve(name string) (string, error) {
+    back := 0
+    switch name {
+    case "latest":
+    case "previous":
+        back = 1
+    default:
+        return name, nil
+    }
+
+    files, err := s.List()
+    if err != nil {
+        return "", err
+    }
+    if len(files) <= back {
+        return "", fmt.Errorf("%s: only %d snapshots in %s", name, len(files), s.Dir)
+    }
+    return files[len(files)-1-back], nil
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+
+    "shuru-hoja/internal/config"
+    "shuru-hoja/pkg/types"
+)
+
+// Rows shown of the images, containers and volumes, largest first.
+const containerTopRows = 10
+
+// showContainers draws the container storage in the manner of
+// "docker system df" and "docker system df -v".
+func showContainers(storage *types.ContainerStorage, cfg *config.Config) {
+    if storage == nil {
+        return
+    }
+
+    title := fmt.Sprintf("CONTAINER STORAGE (%s, %s)", storage.Engine, displayPath(storage.Root, cfg))
+    titledTable(title, []string{"Type", "Total", "Active", "Size", "Reclaimable"}, func(add func(...string)) {
+        for _, u := range storage.Usage {
+            reclaimable := FormatSize(u.Reclaimable)
+            if u.Size > 0 {
+                reclaimable += fmt.Sprintf(" (%.0f%%)", float64(u.Reclaimable)*100/float64(u.Size))
+            }
+            add(u.Type, fmt.Sprint(u.Total), fmt.Sprint(u.Active), FormatSize(u.Size), reclaimable)
+        }
+    })
+
+    if len(storage.Images) > 0 {
+        titledTable("IMAGES", []string{"Image", "ID", "Created", "Layers", "Size", "Unique Size", "Containers"}, func(add func(...string)) {
+            for i, img := range storage.Images {
+                if i == containerTopRows {
+                    break
+                }
+                name := "<none>"
+                if len(img.Tags) > 0 {
+                    name = strings.Join(img.Tags, ", ")
+                }
+                add(name, shortID(img.ID), img.Created.Format("2006-01-02"), fmt.Sprint(img.Layers),
+                    FormatSize(img.Size), FormatSize(img.UniqueSize), fmt.Sprint(img.Containers))
+            }
+        })
+    }
+
+    if len(storage.Containers) > 0 {
+        titledTable("CONTAINERS", []string{"Name", "Image", "Status", "Size", "Logs"}, func(add func(...string)) {
+            for i, c := range storage.Containers {
+                if i == containerTopRows {
+                    break
+                }
+                status := "running"
+                if !c.Running {
+                    status = "stopped"
+                    if c.FinishedAt.Year() > 1 {
+                        status += " " + c.FinishedAt.Format("2006-01-02")
+                    }
+                }
+                name := c.Name
+                if name == "" {
+                    name = shortID(c.ID)
+                }
+                add(name, c.Image, status, FormatSize(c.Size), FormatSize(c.LogSize))
+            }
+        })
+    }
+
+    if len(storage.Volumes) > 0 {
+        titledTable("VOLUMES", []string{"Volume", "Size", "Containers"}, func(add func(...string)) {
+            for i, v := range storage.Volumes {
+                if i == containerTopRows {
+                    break
+                }
+                add(shortID(v.Name), FormatSize(v.Size), fmt.Sprint(v.Containers))
+            }
+        })
+    }
+}
+
+// shortID shortens an ID the way the docker CLI shows it.
+func shortID(id string) string {
+    id = strings.TrimPrefix(id, "sha256:")
+    if len(id) > 12 {
+        return id[:12]
+    }
+    return id
+}
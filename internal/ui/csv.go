@@ -0,0 +1,71 @@
+package ui
+
+import (
+    "encoding/csv"
+    "io"
+    "os/user"
+    "strconv"
+    "strings"
+
+    "shuru-hoja/pkg/types"
+)
+
+var csvHeader = []string{
+    "path", "type", "size", "allocated", "age_days", "owner",
+    "risk", "recommendation", "reason", "duplicate_group",
+}
+
+// WriteCSV writes findings as comma-separated values with a header row,
+// or tab-separated when comma is '\t'. Fields containing the separator,
+// quotes or newlines are quoted. At most limit findings are written, all
+// of them when limit is 0.
+func WriteCSV(w io.Writer, findings []types.ScanResult, comma rune, limit int) error {
+    cw := csv.NewWriter(w)
+    cw.Comma = comma
+    
+    if err := cw.Write(csvHeader); err != nil {
+        return err
+    }
+    
+    owners := make(map[uint32]string)
+    for i, r := range findings {
+        if limit > 0 && i >= limit {
+            break
+        }
+        
+        owner, ok := owners[r.Info.UID]
+        if !ok {
+            owner = ownerName(r.Info.UID)
+            owners[r.Info.UID] = owner
+        }
+        
+        record := []string{
+            r.Info.Path,
+            string(r.Type),
+            strconv.FormatInt(r.Info.Size, 10),
+            strconv.FormatInt(r.Info.Allocated, 10),
+            strconv.Itoa(r.AgeDays),
+            owner,
+            string(r.RiskLevel),
+            string(r.Recommendation),
+            r.Reason,
+            r.DuplicateGroup,
+        }
+        if err := cw.Write(record); err != nil {
+            return err
+        }
+    }
+    
+    cw.Flush()
+    return cw.Error()
+}
+
+// ownerName returns the user name for uid, or the number when it has no
+// entry on this host.
+func ownerName(uid uint32) string {
+    id := strconv.FormatUint(uint64(uid), 10)
+    if u, err := user.LookupId(id); err == nil && strings.TrimSpace(u.Username) != "" {
+        return u.Username
+    }
+    return id
+}
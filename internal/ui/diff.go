@@ -0,0 +1,265 @@
+package ui
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "html/template"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+
+    "github.com/olekukonko/tablewriter"
+    "shuru-hoja/internal/config"
+    "shuru-hoja/pkg/types"
+)
+
+// RenderDiff draws a diff as colored tables.
+func RenderDiff(diff *types.Diff, cfg *config.Config) {
+    if !cfg.Output.Color {
+        DisableColor()
+    }
+
+    fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
+    fmt.Println(ColorWhite + "                     SCAN COMPARISON" + ColorReset)
+    fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
+    fmt.Printf("  From:     %s\n", diff.From.Taken.Format("2006-01-02 15:04 MST"))
+    fmt.Printf("  To:       %s\n", diff.To.Taken.Format("2006-01-02 15:04 MST"))
+    fmt.Printf("  Scanned:  %s -> %s (%s)\n", FormatSize(diff.ScannedBefore),
+        FormatSize(diff.ScannedAfter), colorDelta(diff.ScannedDelta()))
+    fmt.Println()
+
+    if len(diff.Mounts) > 0 {
+        titledTable("USAGE BY FILESYSTEM", []string{"Mount Point", "Before", "After", "Change"}, func(add func(...string)) {
+            for _, m := range diff.Mounts {
+                add(displayPath(m.MountPoint, cfg), FormatSize(m.Before), FormatSize(m.After), colorDelta(m.Delta))
+            }
+        })
+    }
+    if len(diff.Types) > 0 {
+        titledTable("FINDINGS BY TYPE", []string{"Type", "Before", "After", "Change"}, func(add func(...string)) {
+            for _, t := range diff.Types {
+                add(string(t.Type),
+                    fmt.Sprintf("%s (%d)", FormatSize(t.Before), t.BeforeCount),
+                    fmt.Sprintf("%s (%d)", FormatSize(t.After), t.AfterCount),
+                    colorDelta(t.Delta))
+            }
+        })
+    }
+
+    pathTable := func(title string, deltas []types.PathDelta) {
+        if len(deltas) == 0 {
+            return
+        }
+        titledTable(title, []string{"Change", "Before", "After", "Path"}, func(add func(...string)) {
+            for _, p := range deltas {
+                path := displayPath(p.Path, cfg)
+                if p.IsDir {
+                    path += "/"
+                }
+                add(colorDelta(p.Delta), FormatSize(p.Before), FormatSize(p.After), path)
+            }
+        })
+    }
+    pathTable("BIGGEST GROWTH", diff.Grown)
+    pathTable("NEW FILES", diff.NewFiles)
+    pathTable("DELETED FILES", diff.Deleted)
+}
+
+func titledTable(title string, header []string, rows func(add func(...string))) {
+    fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
+    fmt.Printf("%s%*s%s\n", ColorWhite, 29+len(title)/2, title, ColorReset)
+    fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
+
+    table := tablewriter.NewWriter(os.Stdout)
+    table.SetHeader(header)
+    table.SetBorder(true)
+    table.SetAutoWrapText(false)
+    table.SetAutoFormatHeaders(true)
+    rows(func(cells ...string) { table.Append(cells) })
+    table.Render()
+    fmt.Println()
+}
+
+func colorDelta(bytes int64) string {
+    switch {
+    case bytes > 0:
+        return ColorRed + FormatDelta(bytes) + ColorReset
+    case bytes < 0:
+        return ColorGreen + FormatDelta(bytes) + ColorReset
+    }
+    return FormatDelta(bytes)
+}
+
+// WriteDiffJSON writes a diff as one indented JSON document.
+func WriteDiffJSON(w io.Writer, diff *types.Diff) error {
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(diff)
+}
+
+// WriteDiffNDJSON writes one DiffRow per line.
+func WriteDiffNDJSON(w io.Writer, diff *types.Diff) error {
+    enc := json.NewEncoder(w)
+    for _, row := range diff.Rows() {
+        line := struct {
+            SchemaVersion int `json:"schema_version"`
+            types.DiffRow
+        }{types.SchemaVersion, row}
+        if err := enc.Encode(line); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// WriteDiffCSV writes the rows of a diff as comma- or tab-separated values.
+func WriteDiffCSV(w io.Writer, diff *types.Diff, comma rune) error {
+    cw := csv.NewWriter(w)
+    cw.Comma = comma
+
+    cw.Write([]string{"kind", "path", "is_dir", "before", "after", "delta"})
+    for _, row := range diff.Rows() {
+        cw.Write([]string{
+            row.Kind,
+            row.Path,
+            strconv.FormatBool(row.IsDir),
+            strconv.FormatInt(row.Before, 10),
+            strconv.FormatInt(row.After, 10),
+            strconv.FormatInt(row.Delta, 10),
+        })
+    }
+
+    cw.Flush()
+    return cw.Error()
+}
+
+// WriteDiffMarkdown writes a diff as GitHub-flavored Markdown.
+func WriteDiffMarkdown(w io.Writer, diff *types.Diff) error {
+    var b strings.Builder
+
+    fmt.Fprintf(&b, "# Changes on %s\n\n", mdEscape(diff.To.Host))
+    fmt.Fprintf(&b, "From %s to %s. Scanned %s -> %s (%s).\n\n",
+        diff.From.Taken.Format("2006-01-02 15:04 MST"), diff.To.Taken.Format("2006-01-02 15:04 MST"),
+        FormatSize(diff.ScannedBefore), FormatSize(diff.ScannedAfter),
+        FormatDelta(diff.ScannedDelta()))
+
+    if len(diff.Mounts) > 0 {
+        b.WriteString("## Usage by filesystem\n\n| Mount point | Before | After | Change |\n|---|---:|---:|---:|\n")
+        for _, m := range diff.Mounts {
+            fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", mdCode(m.MountPoint),
+                FormatSize(m.Before), FormatSize(m.After), FormatDelta(m.Delta))
+        }
+        b.WriteString("\n")
+    }
+    if len(diff.Types) > 0 {
+        b.WriteString("## Findings by type\n\n| Type | Before | After | Change |\n|---|---:|---:|---:|\n")
+        for _, t := range diff.Types {
+            fmt.Fprintf(&b, "| %s | %s (%d) | %s (%d) | %s |\n", t.Type,
+                FormatSize(t.Before), t.BeforeCount, FormatSize(t.After), t.AfterCount, FormatDelta(t.Delta))
+        }
+        b.WriteString("\n")
+    }
+
+    section := func(title string, deltas []types.PathDelta) {
+        if len(deltas) == 0 {
+            return
+        }
+        fmt.Fprintf(&b, "## %s\n\n| Change | Before | After | Path |\n|---:|---:|---:|---|\n", title)
+        for _, p := range deltas {
+            fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", FormatDelta(p.Delta),
+                FormatSize(p.Before), FormatSize(p.After), mdCode(p.Path))
+        }
+        b.WriteString("\n")
+    }
+    section("Biggest growth", diff.Grown)
+    section("New files", diff.NewFiles)
+    section("Deleted files", diff.Deleted)
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// WriteDiffPrometheus writes the changes between two scans as gauges. The
+// topPaths paths that grew most are exported with their path as a label.
+func WriteDiffPrometheus(w io.Writer, diff *types.Diff, topPaths int) error {
+    var b strings.Builder
+    gauge := func(name, help string) {
+        fmt.Fprintf(&b, "# HELP shuruhoja_diff_%s %s\n# TYPE shuruhoja_diff_%s gauge\n", name, help, name)
+    }
+
+    gauge("interval_seconds", "Time between the two compared scans.")
+    fmt.Fprintf(&b, "shuruhoja_diff_interval_seconds %g\n", diff.To.Taken.Sub(diff.From.Taken).Seconds())
+    gauge("scanned_bytes", "Change in the bytes scanned.")
+    fmt.Fprintf(&b, "shuruhoja_diff_scanned_bytes %d\n", diff.ScannedDelta())
+
+    gauge("finding_bytes", "Change in the bytes held by findings, by finding type.")
+    for _, t := range diff.Types {
+        fmt.Fprintf(&b, "shuruhoja_diff_finding_bytes{type=\"%s\"} %d\n", promLabel(string(t.Type)), t.Delta)
+    }
+    gauge("mount_used_bytes", "Change in the used space of each filesystem.")
+    for _, m := range diff.Mounts {
+        fmt.Fprintf(&b, "shuruhoja_diff_mount_used_bytes{mount_point=\"%s\"} %d\n", promLabel(m.MountPoint), m.Delta)
+    }
+
+    if topPaths > maxTopPaths {
+        topPaths = maxTopPaths
+    }
+    if topPaths > 0 && len(diff.Grown) > 0 {
+        gauge("growth_bytes", "Growth of the paths that grew most.")
+        for i, p := range diff.Grown {
+            if i >= topPaths {
+                break
+            }
+            fmt.Fprintf(&b, "shuruhoja_diff_growth_bytes{path=\"%s\"} %d\n", promLabel(p.Path), p.Delta)
+        }
+    }
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// WriteDiffHTML writes a diff as a single HTML page.
+func WriteDiffHTML(w io.Writer, diff *types.Diff) error {
+    return diffHTMLTemplate.Execute(w, diff)
+}
+
+var diffHTMLTemplate = template.Must(template.Must(htmlBase.Clone()).New("diff").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Shuru Hoja changes{{with .To.Host}} on {{.}}{{end}}</title>
+{{template "style"}}
+</head>
+<body>
+<h1>Shuru Hoja scan comparison</h1>
+<p class="meta">From {{.From.Taken.Format "2006-01-02 15:04 MST"}} to {{.To.Taken.Format "2006-01-02 15:04 MST"}}.
+Scanned {{size .ScannedBefore}} &rarr; {{size .ScannedAfter}} ({{delta .ScannedDelta}}).</p>
+
+{{if .Mounts}}<h2>Usage by filesystem</h2>
+<table>
+<tr><th>Mount point</th><th>Before</th><th>After</th><th>Change</th></tr>
+{{range .Mounts}}<tr><td class="path">{{.MountPoint}}</td><td class="num">{{size .Before}}</td><td class="num">{{size .After}}</td><td class="num">{{delta .Delta}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Types}}<h2>Findings by type</h2>
+<table>
+<tr><th>Type</th><th>Before</th><th>After</th><th>Change</th></tr>
+{{range .Types}}<tr><td>{{.Type}}</td><td class="num">{{size .Before}} ({{.BeforeCount}})</td><td class="num">{{size .After}} ({{.AfterCount}})</td><td class="num">{{delta .Delta}}</td></tr>
+{{end}}</table>{{end}}
+
+{{define "paths"}}<table>
+<tr><th>Change</th><th>Before</th><th>After</th><th>Path</th></tr>
+{{range .}}<tr><td class="num">{{delta .Delta}}</td><td class="num">{{size .Before}}</td><td class="num">{{size .After}}</td><td class="path">{{.Path}}{{if .IsDir}}/{{end}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Grown}}<h2>Biggest growth</h2>
+{{template "paths" .Grown}}{{end}}
+{{if .NewFiles}}<h2>New files</h2>
+{{template "paths" .NewFiles}}{{end}}
+{{if .Deleted}}<h2>Deleted files</h2>
+{{template "paths" .Deleted}}{{end}}
+</body>
+</html>
+`))
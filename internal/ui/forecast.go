@@ -0,0 +1,79 @@
+package ui
+
+import (
+    "fmt"
+    "time"
+
+    "shuru-hoja/internal/config"
+    "shuru-hoja/pkg/types"
+)
+
+func showForecast(forecasts []types.MountForecast, cfg *config.Config) {
+    if len(forecasts) == 0 {
+        return
+    }
+
+    titledTable("GROWTH FORECAST", []string{"Mount Point", "Used", "Free", "Growth/Day", "Full In", "Full Between", "Samples"}, func(add func(...string)) {
+        for _, f := range forecasts {
+            fullIn := "not growing"
+            if f.DaysLeft != nil {
+                fullIn = formatDays(*f.DaysLeft)
+                if *f.DaysLeft < 30 {
+                    fullIn = ColorRed + fullIn + ColorReset
+                }
+            }
+            add(displayPath(f.MountPoint, cfg),
+                FormatSize(f.UsedBytes),
+                FormatSize(f.FreeBytes),
+                FormatDelta(int64(f.Trend.BytesPerDay)),
+                fullIn,
+                fullRange(f),
+                fmt.Sprintf("%d since %s", f.Trend.Samples, f.Trend.Since.Format("2006-01-02")))
+        }
+    })
+
+    var drivers int
+    for _, f := range forecasts {
+        drivers += len(f.Drivers)
+    }
+    if drivers == 0 {
+        return
+    }
+    titledTable("GROWTH DRIVERS", []string{"Mount Point", "Directory", "Size", "Growth/Day", "Share"}, func(add func(...string)) {
+        for _, f := range forecasts {
+            for _, d := range f.Drivers {
+                add(displayPath(f.MountPoint, cfg),
+                    displayPath(d.Path, cfg),
+                    FormatSize(d.Size),
+                    FormatDelta(int64(d.Trend.BytesPerDay)),
+                    fmt.Sprintf("%.0f%%", d.Share*100))
+            }
+        }
+    })
+}
+
+// formatDays rounds a number of days to the unit that reads best.
+func formatDays(days float64) string {
+    switch {
+    case days < 1:
+        return fmt.Sprintf("%.0f hours", days*24)
+    case days < 365:
+        return fmt.Sprintf("%.0f days", days)
+    default:
+        return fmt.Sprintf("%.1f years", days/365)
+    }
+}
+
+// fullRange shows the confidence range of the date a filesystem fills up.
+func fullRange(f types.MountForecast) string {
+    date := func(t *time.Time) string {
+        if t == nil {
+            return "never"
+        }
+        return t.Format("2006-01-02")
+    }
+    if f.FullEarliest == nil {
+        return "-"
+    }
+    return date(f.FullEarliest) + " .. " + date(f.FullLatest)
+}
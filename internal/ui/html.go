@@ -0,0 +1,338 @@
+package ui
+
+import (
+    "html/template"
+    "io"
+
+    "shuru-hoja/pkg/types"
+)
+
+// htmlFinding is a finding as used by the script of the HTML report.
+type htmlFinding struct {
+    Path           string `json:"path"`
+    Type           string `json:"type"`
+    Risk           string `json:"risk"`
+    Recommendation string `json:"rec"`
+    Size           int64  `json:"size"`
+    AgeDays        int    `json:"age"`
+    Owner          string `json:"owner"`
+    Reason         string `json:"reason"`
+    DuplicateGroup string `json:"group"`
+}
+
+type htmlData struct {
+    Report   *types.Report
+    SizeMode string
+    Findings []htmlFinding
+    Tree     []htmlNode
+}
+
+// htmlNode is a DirNode reduced to the size the report is made with.
+type htmlNode struct {
+    Path     string     `json:"path"`
+    Size     int64      `json:"size"`
+    Files    int64      `json:"files"`
+    Children []htmlNode `json:"children,omitempty"`
+}
+
+// WriteHTML writes a report as a single HTML page that needs nothing but a
+// browser: styles, script and data are all inline.
+func WriteHTML(w io.Writer, report *types.Report) error {
+    apparent := report.Scan.ApparentSize
+    data := htmlData{
+        Report:   report,
+        SizeMode: "on disk",
+        Findings: []htmlFinding{},
+        Tree:     []htmlNode{},
+    }
+    if apparent {
+        data.SizeMode = "apparent"
+    }
+
+    owners := make(map[uint32]string)
+    for _, r := range report.Findings {
+        owner, ok := owners[r.Info.UID]
+        if !ok {
+            owner = ownerName(r.Info.UID)
+            owners[r.Info.UID] = owner
+        }
+        data.Findings = append(data.Findings, htmlFinding{
+            Path:           r.Info.Path,
+            Type:           string(r.Type),
+            Risk:           string(r.RiskLevel),
+            Recommendation: string(r.Recommendation),
+            Size:           r.Info.Usage(apparent),
+            AgeDays:        r.AgeDays,
+            Owner:          owner,
+            Reason:         r.Reason,
+            DuplicateGroup: r.DuplicateGroup,
+        })
+    }
+    for _, node := range report.Tree {
+        data.Tree = append(data.Tree, newHTMLNode(node, apparent))
+    }
+
+    return htmlTemplate.Execute(w, data)
+}
+
+func newHTMLNode(node types.DirNode, apparent bool) htmlNode {
+    n := htmlNode{Path: node.Path, Size: node.Allocated, Files: node.Files}
+    if apparent {
+        n.Size = node.Size
+    }
+    for _, child := range node.Children {
+        n.Children = append(n.Children, newHTMLNode(child, apparent))
+    }
+    return n
+}
+
+// htmlBase holds what every HTML page shares.
+var htmlBase = template.Must(template.New("base").Funcs(template.FuncMap{
+    "size":  FormatSize,
+    "delta": FormatDelta,
+}).Parse(`{{define "style"}}<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; background: #fafafa; }
+h1 { font-size: 1.5em; margin-bottom: 0.2em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ccc; }
+.meta { color: #666; }
+table { border-collapse: collapse; width: 100%; background: #fff; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #eee; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+#findings th { cursor: pointer; user-select: none; }
+#findings th.asc::after { content: " \25B2"; }
+#findings th.desc::after { content: " \25BC"; }
+td.num { text-align: right; white-space: nowrap; }
+td.path { word-break: break-all; font-family: monospace; }
+.Critical { color: #c00; font-weight: bold; }
+.Caution { color: #b60; }
+.Safe { color: #080; }
+.summary td:first-child { font-weight: bold; width: 14em; }
+.summary { width: auto; }
+.filters { margin: 0.5em 0; }
+.filters label { margin-right: 1em; }
+#treemap { position: relative; height: 480px; background: #fff; border: 1px solid #ccc; }
+#treemap div { position: absolute; box-sizing: border-box; border: 1px solid #fff; overflow: hidden;
+  font-size: 11px; color: #fff; padding: 2px; cursor: pointer; }
+#crumbs a { cursor: pointer; color: #06c; }
+</style>{{end}}`))
+
+var htmlTemplate = template.Must(template.Must(htmlBase.Clone()).New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Shuru Hoja report{{with .Report.Scan.Host}} for {{.}}{{end}}</title>
+{{template "style"}}
+</head>
+<body>
+<h1>Shuru Hoja disk usage report</h1>
+<p class="meta">
+{{with .Report.Scan}}Host <b>{{.Host}}</b>, scanned {{range $i, $r := .Roots}}{{if $i}}, {{end}}<code>{{$r}}</code>{{end}}
+on {{.StartedAt.Format "2006-01-02 15:04 MST"}} in {{printf "%.1f" .Duration}} s{{if .Quick}} (quick scan){{end}}{{if .Incomplete}}, stopped early by the scan timeout{{end}}.
+Version {{.Version}}.{{end}}
+</p>
+
+<h2>Summary</h2>
+{{with .Report.Summary}}<table class="summary">
+<tr><td>Total scanned</td><td>{{size .TotalScannedBytes}} ({{$.SizeMode}})</td></tr>
+<tr><td>Files</td><td>{{.TotalScannedFiles}}</td></tr>
+<tr><td>Directories</td><td>{{.TotalScannedDirs}}</td></tr>
+<tr><td>Potential cleanup</td><td>{{size .PotentialCleanup}}</td></tr>
+<tr><td>Critical findings</td><td class="Critical">{{.CriticalRiskCount}}</td></tr>
+<tr><td>Caution findings</td><td class="Caution">{{.CautionRiskCount}}</td></tr>
+</table>{{end}}
+
+{{if .Report.Mounts}}<h2>Usage by filesystem</h2>
+<table>
+<tr><th>Mount point</th><th>Type</th><th>Scanned</th><th>Files</th><th>Used</th><th>Free</th><th>Size</th></tr>
+{{range .Report.Mounts}}<tr><td class="path">{{.MountPoint}}</td><td>{{.FSType}}</td><td class="num">{{size .ScannedBytes}}</td>
+<td class="num">{{.ScannedFiles}}</td><td class="num">{{size .UsedBytes}}</td><td class="num">{{size .FreeBytes}}</td><td class="num">{{size .TotalBytes}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Tree}}<h2>Directory sizes</h2>
+<p id="crumbs"></p>
+<div id="treemap"></div>{{end}}
+
+<h2>Findings</h2>
+<div class="filters">
+<label>Type <select id="f-type"><option value="">all</option></select></label>
+<label>Risk <select id="f-risk"><option value="">all</option></select></label>
+<label>Owner <select id="f-owner"><option value="">all</option></select></label>
+<label>Path <input id="f-path" type="search" placeholder="contains"></label>
+<span id="count" class="meta"></span>
+</div>
+<table id="findings">
+<thead><tr>
+<th data-key="size" data-num="1">Size</th><th data-key="type">Type</th><th data-key="risk">Risk</th>
+<th data-key="rec">Recommendation</th><th data-key="age" data-num="1">Age (days)</th><th data-key="owner">Owner</th>
+<th data-key="path">Path</th><th data-key="reason">Reason</th>
+</tr></thead>
+<tbody></tbody>
+</table>
+
+{{if .Report.Errors}}<h2>Errors</h2>
+<ul>{{range .Report.Errors}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+
+<script>
+"use strict";
+const findings = {{.Findings}};
+const tree = {{.Tree}};
+const maxRows = 2000;
+
+function formatSize(b) {
+  const units = "KMGTPE";
+  if (b < 1024) return b + " B";
+  let exp = -1;
+  while (b >= 1024 && exp < units.length - 1) { b /= 1024; exp++; }
+  return b.toFixed(1) + " " + units[exp] + "B";
+}
+
+function cell(row, text, cls) {
+  const td = row.insertCell();
+  td.textContent = text;
+  if (cls) td.className = cls;
+}
+
+// Findings table with sorting and filters
+let sortKey = "size", sortDesc = true;
+const filters = { type: "f-type", risk: "f-risk", owner: "f-owner" };
+
+for (const [key, id] of Object.entries(filters)) {
+  const select = document.getElementById(id);
+  [...new Set(findings.map(f => f[key]))].sort().forEach(v => select.add(new Option(v, v)));
+  select.addEventListener("change", drawFindings);
+}
+document.getElementById("f-path").addEventListener("input", drawFindings);
+
+document.querySelectorAll("#findings th").forEach(th => th.addEventListener("click", () => {
+  const key = th.dataset.key;
+  sortDesc = key === sortKey ? !sortDesc : th.dataset.num === "1";
+  sortKey = key;
+  drawFindings();
+}));
+
+function drawFindings() {
+  const path = document.getElementById("f-path").value;
+  const shown = findings.filter(f => {
+    for (const [key, id] of Object.entries(filters)) {
+      const v = document.getElementById(id).value;
+      if (v && f[key] !== v) return false;
+    }
+    return !path || f.path.includes(path);
+  });
+  shown.sort((a, b) => {
+    const x = a[sortKey], y = b[sortKey];
+    const c = typeof x === "number" ? x - y : String(x).localeCompare(String(y));
+    return sortDesc ? -c : c;
+  });
+
+  document.querySelectorAll("#findings th").forEach(th => {
+    th.className = th.dataset.key === sortKey ? (sortDesc ? "desc" : "asc") : "";
+  });
+
+  const body = document.querySelector("#findings tbody");
+  body.replaceChildren();
+  for (const f of shown.slice(0, maxRows)) {
+    const row = body.insertRow();
+    cell(row, formatSize(f.size), "num");
+    cell(row, f.type);
+    cell(row, f.risk, f.risk);
+    cell(row, f.rec);
+    cell(row, f.age, "num");
+    cell(row, f.owner);
+    cell(row, f.path, "path");
+    cell(row, f.reason);
+  }
+  document.getElementById("count").textContent = shown.length > maxRows
+    ? "showing " + maxRows + " of " + shown.length + " matching findings"
+    : shown.length + " of " + findings.length + " findings";
+}
+drawFindings();
+
+// Squarified treemap of the directory outline; click a box to zoom in
+function squarify(nodes, x, y, w, h, out) {
+  nodes = nodes.filter(n => n.size > 0);
+  const total = nodes.reduce((s, n) => s + n.size, 0);
+  if (!total || w <= 0 || h <= 0) return;
+  const scale = w * h / total;
+  let row = [], rest = nodes.slice();
+
+  function worst(row, side) {
+    const areas = row.map(n => n.size * scale);
+    const sum = areas.reduce((a, b) => a + b, 0);
+    const max = Math.max(...areas), min = Math.min(...areas);
+    return Math.max(side * side * max / (sum * sum), sum * sum / (side * side * min));
+  }
+  function layout(row) {
+    const sum = row.reduce((s, n) => s + n.size * scale, 0);
+    if (w >= h) {
+      const rw = sum / h;
+      let cy = y;
+      for (const n of row) { const nh = n.size * scale / rw; out.push([n, x, cy, rw, nh]); cy += nh; }
+      x += rw; w -= rw;
+    } else {
+      const rh = sum / w;
+      let cx = x;
+      for (const n of row) { const nw = n.size * scale / rh; out.push([n, cx, y, nw, rh]); cx += nw; }
+      y += rh; h -= rh;
+    }
+  }
+  while (rest.length) {
+    const side = Math.min(w, h);
+    const next = rest[0];
+    if (!row.length || worst(row.concat([next]), side) <= worst(row, side)) {
+      row.push(rest.shift());
+    } else {
+      layout(row);
+      row = [];
+    }
+  }
+  if (row.length) layout(row);
+}
+
+let treeStack = [];
+
+function drawTreemap(stack) {
+  treeStack = stack;
+  const box = document.getElementById("treemap");
+  const node = stack[stack.length - 1];
+  box.replaceChildren();
+
+  const crumbs = document.getElementById("crumbs");
+  crumbs.replaceChildren();
+  stack.forEach((n, i) => {
+    if (i) crumbs.append(" / ");
+    const a = document.createElement("a");
+    a.textContent = n.path + " (" + formatSize(n.size) + ")";
+    a.addEventListener("click", () => drawTreemap(stack.slice(0, i + 1)));
+    crumbs.append(a);
+  });
+
+  const children = (node.children || []).slice();
+  const shown = children.reduce((s, n) => s + n.size, 0);
+  if (node.size > shown) children.push({ path: "(files and smaller directories)", size: node.size - shown });
+
+  const rects = [];
+  squarify(children, 0, 0, box.clientWidth, box.clientHeight, rects);
+  rects.forEach(([n, x, y, w, h], i) => {
+    const div = document.createElement("div");
+    Object.assign(div.style, { left: x + "px", top: y + "px", width: w + "px", height: h + "px",
+      background: "hsl(" + (i * 47 % 360) + ", 45%, 45%)" });
+    const name = n.path.split("/").pop() || n.path;
+    div.textContent = name + " " + formatSize(n.size);
+    div.title = n.path + "\n" + formatSize(n.size) + (n.files ? ", " + n.files + " files" : "");
+    if (n.children) div.addEventListener("click", () => drawTreemap(stack.concat([n])));
+    box.append(div);
+  });
+}
+
+if (tree.length === 1) {
+  drawTreemap([tree[0]]);
+} else if (tree.length > 1) {
+  drawTreemap([{ path: "all roots", size: tree.reduce((s, n) => s + n.size, 0), children: tree }]);
+}
+window.addEventListener("resize", () => { if (treeStack.length) drawTreemap(treeStack); });
+</script>
+</body>
+</html>
+`))
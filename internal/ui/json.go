@@ -0,0 +1,57 @@
+package ui
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+
+    "shuru-hoja/pkg/types"
+)
+
+// WriteJSON writes a report as one indented JSON document.
+func WriteJSON(w io.Writer, report *types.Report) error {
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(report)
+}
+
+// ndjsonLine is a finding as written by NDJSONWriter: the fields of the
+// result with the schema version next to them.
+type ndjsonLine struct {
+    SchemaVersion int `json:"schema_version"`
+    types.ScanResult
+}
+
+// NDJSONWriter writes one finding per line as they arrive.
+type NDJSONWriter struct {
+    enc *json.Encoder
+    err error
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+    return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+// Write writes a finding. After the first error it does nothing; Err
+// returns that error.
+func (n *NDJSONWriter) Write(r types.ScanResult) {
+    if n.err == nil {
+        n.err = n.enc.Encode(ndjsonLine{SchemaVersion: types.SchemaVersion, ScanResult: r})
+    }
+}
+
+func (n *NDJSONWriter) Err() error {
+    return n.err
+}
+
+// ReadJSON reads a report written by WriteJSON.
+func ReadJSON(r io.Reader) (*types.Report, error) {
+    var report types.Report
+    if err := json.NewDecoder(r).Decode(&report); err != nil {
+        return nil, err
+    }
+    if report.SchemaVersion < 1 || report.SchemaVersion > types.SchemaVersion {
+        return nil, fmt.Errorf("unsupported schema version %d", report.SchemaVersion)
+    }
+    return &report, nil
+}
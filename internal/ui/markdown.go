@@ -0,0 +1,174 @@
+package ui
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+
+    "shuru-hoja/internal/config"
+    "shuru-hoja/pkg/types"
+)
+
+// categoryTotal adds up the findings of one type.
+type categoryTotal struct {
+    Type  types.FileType
+    Count int
+    Bytes int64
+}
+
+// categoryTotals groups findings by type, largest total first.
+func categoryTotals(findings []types.ScanResult, apparent bool) []categoryTotal {
+    byType := make(map[types.FileType]*categoryTotal)
+    var totals []*categoryTotal
+    for _, r := range findings {
+        t, ok := byType[r.Type]
+        if !ok {
+            t = &categoryTotal{Type: r.Type}
+            byType[r.Type] = t
+            totals = append(totals, t)
+        }
+        t.Count++
+        t.Bytes += r.Info.Usage(apparent)
+    }
+
+    sort.SliceStable(totals, func(i, j int) bool {
+        return totals[i].Bytes > totals[j].Bytes
+    })
+    result := make([]categoryTotal, len(totals))
+    for i, t := range totals {
+        result[i] = *t
+    }
+    return result
+}
+
+// WriteMarkdown writes a report as GitHub-flavored Markdown, for pasting
+// into tickets and wiki pages.
+func WriteMarkdown(w io.Writer, report *types.Report, cfg *config.Config) error {
+    apparent := report.Scan.ApparentSize
+    var b strings.Builder
+
+    sizeMode := "on disk"
+    if apparent {
+        sizeMode = "apparent"
+    }
+
+    fmt.Fprintf(&b, "# Disk usage report for %s\n\n", mdEscape(report.Scan.Host))
+    var roots []string
+    for _, root := range report.Scan.Roots {
+        roots = append(roots, mdCode(root))
+    }
+    fmt.Fprintf(&b, "Scanned %s on %s in %.1f s", strings.Join(roots, ", "),
+        report.Scan.StartedAt.Format("2006-01-02 15:04 MST"), report.Scan.Duration)
+    if report.Scan.Quick {
+        b.WriteString(" (quick scan)")
+    }
+    if report.Scan.Incomplete {
+        b.WriteString(", stopped early by the scan timeout")
+    }
+    b.WriteString(".\n\n")
+
+    if cfg.Output.ShowSummary {
+        s := report.Summary
+        b.WriteString("## Summary\n\n")
+        b.WriteString("| | |\n|---|---:|\n")
+        fmt.Fprintf(&b, "| Total scanned | %s (%s) |\n", FormatSize(s.TotalScannedBytes), sizeMode)
+        fmt.Fprintf(&b, "| Files | %d |\n", s.TotalScannedFiles)
+        fmt.Fprintf(&b, "| Directories | %d |\n", s.TotalScannedDirs)
+        fmt.Fprintf(&b, "| Potential cleanup | %s |\n", FormatSize(s.PotentialCleanup))
+        fmt.Fprintf(&b, "| Critical risk items | %d |\n", s.CriticalRiskCount)
+        fmt.Fprintf(&b, "| Caution risk items | %d |\n\n", s.CautionRiskCount)
+    }
+
+    if totals := categoryTotals(report.Findings, apparent); len(totals) > 0 {
+        b.WriteString("## Findings by category\n\n")
+        b.WriteString("| Type | Findings | Size |\n|---|---:|---:|\n")
+        for _, t := range totals {
+            fmt.Fprintf(&b, "| %s | %d | %s |\n", t.Type, t.Count, FormatSize(t.Bytes))
+        }
+        b.WriteString("\n")
+    }
+
+    if len(report.Findings) == 0 {
+        b.WriteString("No cleanup recommendations found.\n")
+        _, err := io.WriteString(w, b.String())
+        return err
+    }
+
+    b.WriteString("## Top cleanup recommendations\n\n")
+    b.WriteString("| Size | Type | Risk | Recommendation | Path |\n|---:|---|---|---|---|\n")
+    for i, r := range report.Findings {
+        if cfg.Output.MaxResults > 0 && i >= cfg.Output.MaxResults {
+            break
+        }
+        fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+            FormatSize(r.Info.Usage(apparent)), r.Type, r.RiskLevel, r.Recommendation,
+            mdCode(r.Info.Path))
+    }
+    b.WriteString("\n")
+
+    if cfg.Output.ShowRecommendations {
+        var critical, caution []types.ScanResult
+        for _, r := range report.Findings {
+            if r.RiskLevel == types.RiskCritical && r.Recommendation == types.RecDelete {
+                critical = append(critical, r)
+            } else if r.RiskLevel == types.RiskCaution && r.Recommendation == types.RecReview {
+                caution = append(caution, r)
+            }
+        }
+        mdRecommendations(&b, "Critical recommendations", critical, apparent)
+        mdRecommendations(&b, "Caution recommendations", caution, apparent)
+    }
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// mdRecommendations lists the first ten results under a heading.
+func mdRecommendations(b *strings.Builder, title string, results []types.ScanResult, apparent bool) {
+    if len(results) == 0 {
+        return
+    }
+
+    fmt.Fprintf(b, "## %s\n\n", title)
+    for i, r := range results {
+        if i >= 10 {
+            break
+        }
+        fmt.Fprintf(b, "- **%s** %s: %s\n", FormatSize(r.Info.Usage(apparent)),
+            mdCode(r.Info.Path), mdEscape(r.Reason))
+    }
+    b.WriteString("\n")
+}
+
+// mdEscape makes text safe for Markdown, also inside table cells, by
+// escaping inline markup and spelling out line breaks. Text never starts a
+// line, so block markup such as # needs no escaping.
+func mdEscape(s string) string {
+    var b strings.Builder
+    for _, c := range s {
+        switch {
+        case c == '\n':
+            b.WriteString(`\\n`)
+        case c == '\r':
+            b.WriteString(`\\r`)
+        case c == '\t':
+            b.WriteString(`\\t`)
+        case strings.ContainsRune("\\`*_[]<>|~", c):
+            b.WriteByte('\\')
+            b.WriteRune(c)
+        default:
+            b.WriteRune(c)
+        }
+    }
+    return b.String()
+}
+
+// mdCode shows a path in monospace. Code spans do not interpret escapes,
+// so paths that need any are written as escaped text instead.
+func mdCode(path string) string {
+    if strings.ContainsAny(path, "`|\n\r\t") {
+        return mdEscape(path)
+    }
+    return "`" + path + "`"
+}
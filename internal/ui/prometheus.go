@@ -0,0 +1,151 @@
+package ui
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "shuru-hoja/pkg/types"
+)
+
+// maxTopPaths bounds the number of per-path series whatever is configured,
+// since every path is a series of its own in Prometheus.
+const maxTopPaths = 1000
+
+// WritePrometheus writes a report as gauges in the Prometheus text format,
+// as read by the node_exporter textfile collector. The topPaths largest
+// findings are exported with their path as a label.
+func WritePrometheus(w io.Writer, report *types.Report, topPaths int) error {
+    apparent := report.Scan.ApparentSize
+    var b strings.Builder
+
+    gauge := func(name, help string) {
+        fmt.Fprintf(&b, "# HELP shuruhoja_%s %s\n# TYPE shuruhoja_%s gauge\n", name, help, name)
+    }
+
+    // Every type gets a series, so that alerts see zero rather than nothing
+    byType := make(map[types.FileType]categoryTotal)
+    typeNames := append([]types.FileType(nil), types.FileTypes...)
+    for _, t := range categoryTotals(report.Findings, apparent) {
+        byType[t.Type] = t
+        if !containsType(typeNames, t.Type) {
+            typeNames = append(typeNames, t.Type)
+        }
+    }
+
+    gauge("finding_bytes", "Bytes held by findings, by finding type.")
+    for _, t := range typeNames {
+        fmt.Fprintf(&b, "shuruhoja_finding_bytes{type=\"%s\"} %d\n", promLabel(string(t)), byType[t].Bytes)
+    }
+    gauge("findings", "Number of findings, by finding type.")
+    for _, t := range typeNames {
+        fmt.Fprintf(&b, "shuruhoja_findings{type=\"%s\"} %d\n", promLabel(string(t)), byType[t].Count)
+    }
+
+    byRisk := make(map[types.RiskLevel]int64)
+    for _, r := range report.Findings {
+        byRisk[r.RiskLevel] += r.Info.Usage(apparent)
+    }
+    gauge("risk_bytes", "Bytes held by findings, by risk level.")
+    for _, risk := range []types.RiskLevel{types.RiskCaution, types.RiskCritical} {
+        fmt.Fprintf(&b, "shuruhoja_risk_bytes{risk=\"%s\"} %d\n", strings.ToLower(string(risk)), byRisk[risk])
+    }
+
+    s := report.Summary
+    gauge("potential_cleanup_bytes", "Bytes freed by deleting every finding recommended for deletion.")
+    fmt.Fprintf(&b, "shuruhoja_potential_cleanup_bytes %d\n", s.PotentialCleanup)
+    gauge("scanned_bytes", "Bytes scanned, hard-linked data counted once.")
+    fmt.Fprintf(&b, "shuruhoja_scanned_bytes %d\n", s.TotalScannedBytes)
+    gauge("scanned_files", "Files scanned.")
+    fmt.Fprintf(&b, "shuruhoja_scanned_files %d\n", s.TotalScannedFiles)
+    gauge("scanned_dirs", "Directories scanned.")
+    fmt.Fprintf(&b, "shuruhoja_scanned_dirs %d\n", s.TotalScannedDirs)
+    gauge("permission_errors", "Directories that could not be read.")
+    fmt.Fprintf(&b, "shuruhoja_permission_errors %d\n", s.PermissionErrors)
+    gauge("scan_duration_seconds", "Time the scan took.")
+    fmt.Fprintf(&b, "shuruhoja_scan_duration_seconds %g\n", report.Scan.Duration)
+    gauge("scan_timestamp_seconds", "Unix time the scan started.")
+    fmt.Fprintf(&b, "shuruhoja_scan_timestamp_seconds %d\n", report.Scan.StartedAt.Unix())
+
+    if topPaths > maxTopPaths {
+        topPaths = maxTopPaths
+    }
+    if topPaths > 0 && len(report.Findings) > 0 {
+        top := largestFindings(report.Findings, topPaths, apparent)
+        gauge("top_finding_bytes", "Bytes held by the largest findings.")
+        for _, r := range top {
+            fmt.Fprintf(&b, "shuruhoja_top_finding_bytes{path=\"%s\",type=\"%s\",risk=\"%s\"} %d\n",
+                promLabel(r.Info.Path), promLabel(string(r.Type)),
+                strings.ToLower(string(r.RiskLevel)), r.Info.Usage(apparent))
+        }
+    }
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// WritePrometheusFile writes the metrics to path atomically, so that the
+// textfile collector never reads a partly written file.
+func WritePrometheusFile(path string, report *types.Report, topPaths int) error {
+    return WriteAtomic(path, func(w io.Writer) error {
+        return WritePrometheus(w, report, topPaths)
+    })
+}
+
+// WriteAtomic replaces path with what write produces, so that readers see
+// either the old or the new file but never a partly written one. The
+// temporary file starts with a dot, which collectors and globs skip.
+func WriteAtomic(path string, write func(w io.Writer) error) error {
+    tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    if err := write(tmp); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Chmod(0644); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+
+    return os.Rename(tmp.Name(), path)
+}
+
+// largestFindings returns the n largest findings, largest first.
+func largestFindings(findings []types.ScanResult, n int, apparent bool) []types.ScanResult {
+    top := append([]types.ScanResult(nil), findings...)
+    sort.SliceStable(top, func(i, j int) bool {
+        return top[i].Info.Usage(apparent) > top[j].Info.Usage(apparent)
+    })
+    if len(top) > n {
+        top = top[:n]
+    }
+    return top
+}
+
+func containsType(list []types.FileType, t types.FileType) bool {
+    for _, v := range list {
+        if v == t {
+            return true
+        }
+    }
+    return false
+}
+
+// promLabel escapes a label value for the text exposition format.
+func promLabel(s string) string {
+    return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
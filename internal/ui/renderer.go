@@ -1,11 +1,13 @@
 package ui
 
 import (
+    "errors"
     "fmt"
+    "io/fs"
     "os"
-    "time"
 
     "github.com/olekukonko/tablewriter"
+    "shuru-hoja/internal/config"
     "shuru-hoja/pkg/types"
 )
 
@@ -31,21 +33,68 @@ func ShowWelcome() {
     fmt.Println()
 }
 
-func RenderResults(results []types.ScanResult, duration time.Duration, maxResults int) {
-    // Calculate summary
-    summary := CalculateSummary(results)
+// DisableColor turns off ANSI colors for all further output.
+func DisableColor() {
+    ColorReset, ColorRed, ColorGreen, ColorYellow = "", "", "", ""
+    ColorBlue, ColorPurple, ColorCyan, ColorWhite = "", "", "", ""
+}
+
+// NewReport collects what a scan produced into a report. Only findings are
+// kept from the results; the summary covers all of them.
+func NewReport(scan types.ScanInfo, results []types.ScanResult, mounts []types.MountSummary, errs []error) *types.Report {
+    report := &types.Report{
+        SchemaVersion: types.SchemaVersion,
+        Scan:          scan,
+        Summary:       CalculateSummary(results, scan.ApparentSize),
+        Mounts:        mounts,
+        Findings:      []types.ScanResult{},
+        Errors:        []string{},
+    }
+    if report.Mounts == nil {
+        report.Mounts = []types.MountSummary{}
+    }
+    
+    for _, r := range results {
+        if r.IsFinding() {
+            report.Findings = append(report.Findings, r)
+        }
+    }
+    for _, err := range errs {
+        report.Errors = append(report.Errors, err.Error())
+        if errors.Is(err, fs.ErrPermission) {
+            report.Summary.PermissionErrors++
+        }
+    }
+    
+    return report
+}
+
+// RenderReport draws a report as colored tables.
+func RenderReport(report *types.Report, cfg *config.Config) {
+    if !cfg.Output.Color {
+        DisableColor()
+    }
+    
+    if cfg.Output.ShowSummary {
+        showSummary(report.Summary, report.Scan)
+    }
     
-    // Show summary first
-    showSummary(summary, duration)
+    if cfg.Output.ShowStatistics {
+        // Show where the scanned data lives
+        showMounts(report.Mounts, cfg)
+        showForecast(report.Forecast, cfg)
+    }
+    showContainers(report.Containers, cfg)
     
     // Show table of top findings
-    ShowTopFindings(results, maxResults)
+    ShowTopFindings(report.Findings, cfg)
     
-    // Show recommendations
-    ShowRecommendations(results)
+    if cfg.Output.ShowRecommendations {
+        ShowRecommendations(report.Findings, cfg)
+    }
 }
 
-func showSummary(summary Summary, duration time.Duration) {
+func showSummary(summary types.Summary, scan types.ScanInfo) {
     fmt.Println()
     fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
     fmt.Println(ColorWhite + "                     SCAN SUMMARY" + ColorReset)
@@ -57,17 +106,56 @@ func showSummary(summary Summary, duration time.Duration) {
     table.SetAlignment(tablewriter.ALIGN_LEFT)
     table.SetAutoWrapText(false)
     
+    sizeMode := "on disk"
+    if scan.ApparentSize {
+        sizeMode = "apparent"
+    }
+    
     data := [][]string{
-        {"Total Scanned:", fmt.Sprintf("%.2f GB", float64(summary.TotalScannedBytes)/(1024*1024*1024))},
+        {"Total Scanned:", fmt.Sprintf("%.2f GB (%s)", float64(summary.TotalScannedBytes)/(1024*1024*1024), sizeMode)},
         {"Total Files:", fmt.Sprintf("%d", summary.TotalScannedFiles)},
         {"Total Directories:", fmt.Sprintf("%d", summary.TotalScannedDirs)},
         {"Potential Cleanup:", fmt.Sprintf("%.2f GB", float64(summary.PotentialCleanup)/(1024*1024*1024))},
         {"Critical Risk Items:", fmt.Sprintf("%d", summary.CriticalRiskCount)},
         {"Caution Risk Items:", fmt.Sprintf("%d", summary.CautionRiskCount)},
-        {"Scan Duration:", fmt.Sprintf("%.2f seconds", duration.Seconds())},
+        {"Scan Duration:", fmt.Sprintf("%.2f seconds", scan.Elapsed().Seconds())},
+    }
+    if scan.Incomplete {
+        data = append(data, []string{"Incomplete:", "stopped by scan_timeout_minutes"})
     }
     
     table.AppendBulk(data)
     table.Render()
     fmt.Println()
 }
+
+func showMounts(mounts []types.MountSummary, cfg *config.Config) {
+    if len(mounts) == 0 {
+        return
+    }
+    
+    fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
+    fmt.Println(ColorWhite + "                   USAGE BY FILESYSTEM" + ColorReset)
+    fmt.Println(ColorCyan + "══════════════════════════════════════════════════════════" + ColorReset)
+    
+    table := tablewriter.NewWriter(os.Stdout)
+    table.SetHeader([]string{"Mount Point", "Type", "Scanned", "Files", "Used", "Free", "Size"})
+    table.SetBorder(true)
+    table.SetAutoWrapText(false)
+    table.SetAutoFormatHeaders(true)
+    
+    for _, m := range mounts {
+        table.Append([]string{
+            displayPath(m.MountPoint, cfg),
+            m.FSType,
+            FormatSize(m.ScannedBytes),
+            fmt.Sprintf("%d", m.ScannedFiles),
+            FormatSize(m.UsedBytes),
+            FormatSize(m.FreeBytes),
+            FormatSize(m.TotalBytes),
+        })
+    }
+    
+    table.Render()
+    fmt.Println()
+}
@@ -6,31 +6,48 @@ import (
     "strconv"
 
     "github.com/olekukonko/tablewriter"
+    "shuru-hoja/internal/config"
     "shuru-hoja/pkg/types"
 )
 
-type Summary struct {
-    TotalScannedBytes   int64
-    TotalScannedFiles   int64
-    TotalScannedDirs    int64
-    PotentialCleanup    int64
-    CriticalRiskCount   int64
-    CautionRiskCount    int64
-}
-
-func CalculateSummary(results []types.ScanResult) Summary {
-    var summary Summary
+// CalculateSummary totals the results using allocated disk usage, or the
+// apparent size when apparent is set. Hard-linked data is counted once, and
+// only counts towards the potential cleanup when every link is deleted.
+func CalculateSummary(results []types.ScanResult, apparent bool) types.Summary {
+    var summary types.Summary
+    
+    type fileID struct{ dev, inode uint64 }
+    seen := make(map[fileID]bool)
+    deleted := make(map[fileID]uint64)
     
     for _, r := range results {
-        summary.TotalScannedBytes += r.Info.Size
+        // Directory findings carry the size of their whole subtree, which
+        // is already counted through the files below them.
         if r.Info.IsDir {
             summary.TotalScannedDirs++
         } else {
             summary.TotalScannedFiles++
+            if r.Info.SharesData() {
+                id := fileID{r.Info.Device, r.Info.Inode}
+                if !seen[id] {
+                    summary.TotalScannedBytes += r.Info.Usage(apparent)
+                }
+                seen[id] = true
+            } else {
+                summary.TotalScannedBytes += r.Info.Usage(apparent)
+            }
         }
         
         if r.Recommendation == types.RecDelete {
-            summary.PotentialCleanup += r.Info.Size
+            if r.Info.SharesData() {
+                id := fileID{r.Info.Device, r.Info.Inode}
+                deleted[id]++
+                if deleted[id] == r.Info.HardLinks {
+                    summary.PotentialCleanup += r.Info.Usage(apparent)
+                }
+            } else {
+                summary.PotentialCleanup += r.Info.Usage(apparent)
+            }
         }
         
         switch r.RiskLevel {
@@ -44,14 +61,18 @@ func CalculateSummary(results []types.ScanResult) Summary {
     return summary
 }
 
-func ShowTopFindings(results []types.ScanResult, maxResults int) {
+func ShowTopFindings(results []types.ScanResult, cfg *config.Config) {
+    maxResults := cfg.Output.MaxResults
+    apparent := cfg.Output.ApparentSize
+    
     // Filter only items with recommendations
     var filtered []types.ScanResult
     for _, r := range results {
-        if r.Recommendation != types.RecKeep && r.RiskLevel != types.RiskSafe {
+        if r.IsFinding() {
             filtered = append(filtered, r)
         }
-        if len(filtered) >= maxResults {
+        // 0 shows them all
+        if maxResults > 0 && len(filtered) >= maxResults {
             break
         }
     }
@@ -72,7 +93,7 @@ func ShowTopFindings(results []types.ScanResult, maxResults int) {
     table.SetAutoFormatHeaders(true)
     
     for _, r := range filtered {
-        size := FormatSize(r.Info.Size)
+        size := FormatSize(r.Info.Usage(apparent))
         riskColor := GetRiskColor(r.RiskLevel)
         recColor := GetRecommendationColor(r.Recommendation)
         
@@ -81,7 +102,7 @@ func ShowTopFindings(results []types.ScanResult, maxResults int) {
             string(r.Type),
             riskColor + string(r.RiskLevel) + ColorReset,
             recColor + string(r.Recommendation) + ColorReset,
-            TruncatePath(r.Info.Path, 50),
+            displayPath(r.Info.Path, cfg),
         }
         table.Append(row)
     }
@@ -103,6 +124,14 @@ func FormatSize(bytes int64) string {
         " " + string("KMGTPE"[exp]) + "B"
 }
 
+// FormatDelta formats a change in size with its sign.
+func FormatDelta(bytes int64) string {
+    if bytes < 0 {
+        return "-" + FormatSize(-bytes)
+    }
+    return "+" + FormatSize(bytes)
+}
+
 func TruncatePath(path string, maxLength int) string {
     if len(path) <= maxLength {
         return path
@@ -113,6 +142,15 @@ func TruncatePath(path string, maxLength int) string {
     return path[:keep] + "..." + path[len(path)-keep:]
 }
 
+// displayPath shortens a path to the configured length unless full paths
+// were asked for.
+func displayPath(path string, cfg *config.Config) string {
+    if cfg.Output.ShowFullPath || cfg.Output.TruncatePathLength <= 0 {
+        return path
+    }
+    return TruncatePath(path, cfg.Output.TruncatePathLength)
+}
+
 func GetRiskColor(risk types.RiskLevel) string {
     switch risk {
     case types.RiskCritical:
@@ -135,7 +173,9 @@ func GetRecommendationColor(rec types.Recommendation) string {
     }
 }
 
-func ShowRecommendations(results []types.ScanResult) {
+func ShowRecommendations(results []types.ScanResult, cfg *config.Config) {
+    apparent := cfg.Output.ApparentSize
+    
     var critical, caution []types.ScanResult
     
     for _, r := range results {
@@ -157,8 +197,8 @@ func ShowRecommendations(results []types.ScanResult) {
                 break
             }
             fmt.Printf("%s• %s%s - %s (%s)%s\n", 
-                ColorRed, FormatSize(r.Info.Size), ColorReset,
-                TruncatePath(r.Info.Path, 60),
+                ColorRed, FormatSize(r.Info.Usage(apparent)), ColorReset,
+                displayPath(r.Info.Path, cfg),
                 r.Reason, ColorReset)
         }
     }
@@ -174,8 +214,8 @@ func ShowRecommendations(results []types.ScanResult) {
                 break
             }
             fmt.Printf("%s• %s%s - %s (%s)%s\n", 
-                ColorYellow, FormatSize(r.Info.Size), ColorReset,
-                TruncatePath(r.Info.Path, 60),
+                ColorYellow, FormatSize(r.Info.Usage(apparent)), ColorReset,
+                displayPath(r.Info.Path, cfg),
                 r.Reason, ColorReset)
         }
     }
@@ -0,0 +1,56 @@
+package types
+
+import (
+    "time"
+)
+
+// ContainerStorage is the disk usage of a container engine, read from its
+// files: the equivalent of "docker system df -v".
+type ContainerStorage struct {
+    Engine     string           `json:"engine"`
+    Root       string           `json:"root"`
+    Usage      []StorageUsage   `json:"usage"`
+    Images     []ImageUsage     `json:"images"`
+    Containers []ContainerUsage `json:"containers"`
+    Volumes    []VolumeUsage    `json:"volumes"`
+}
+
+// StorageUsage is one line of "docker system df": Type is "Images",
+// "Containers", "Local Volumes", "Build Cache" or "Container Logs".
+type StorageUsage struct {
+    Type        string `json:"type"`
+    Total       int    `json:"total"`
+    Active      int    `json:"active"`
+    Size        int64  `json:"size"`
+    Reclaimable int64  `json:"reclaimable"`
+}
+
+// ImageUsage attributes layers to an image. Size counts every layer of the
+// image, UniqueSize only those no other image uses.
+type ImageUsage struct {
+    ID         string    `json:"id"`
+    Tags       []string  `json:"tags"`
+    Created    time.Time `json:"created"`
+    Layers     int       `json:"layers"`
+    Size       int64     `json:"size"`
+    UniqueSize int64     `json:"unique_size"`
+    Containers int       `json:"containers"`
+}
+
+// ContainerUsage is the writable layer and log of one container.
+type ContainerUsage struct {
+    ID         string    `json:"id"`
+    Name       string    `json:"name"`
+    Image      string    `json:"image"`
+    Running    bool      `json:"running"`
+    FinishedAt time.Time `json:"finished_at"`
+    Size       int64     `json:"size"`
+    LogSize    int64     `json:"log_size"`
+}
+
+// VolumeUsage is one local volume and the containers mounting it.
+type VolumeUsage struct {
+    Name       string `json:"name"`
+    Size       int64  `json:"size"`
+    Containers int    `json:"containers"`
+}
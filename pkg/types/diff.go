@@ -0,0 +1,94 @@
+package types
+
+import (
+    "time"
+)
+
+// SnapshotInfo identifies one side of a diff.
+type SnapshotInfo struct {
+    File  string    `json:"file"`
+    Host  string    `json:"host"`
+    Roots []string  `json:"roots"`
+    Taken time.Time `json:"taken"`
+}
+
+// PathDelta is the change in size of one path between two snapshots. For
+// new paths Before is 0, for deleted ones After is 0.
+type PathDelta struct {
+    Path   string `json:"path"`
+    IsDir  bool   `json:"is_dir"`
+    Before int64  `json:"before"`
+    After  int64  `json:"after"`
+    Delta  int64  `json:"delta"`
+}
+
+// TypeDelta is the change in the findings of one type.
+type TypeDelta struct {
+    Type        FileType `json:"type"`
+    BeforeCount int      `json:"before_count"`
+    AfterCount  int      `json:"after_count"`
+    Before      int64    `json:"before"`
+    After       int64    `json:"after"`
+    Delta       int64    `json:"delta"`
+}
+
+// MountDelta is the change in the used space of one filesystem.
+type MountDelta struct {
+    MountPoint string `json:"mount_point"`
+    Before     int64  `json:"before"`
+    After      int64  `json:"after"`
+    Delta      int64  `json:"delta"`
+}
+
+// Diff compares two scans, as written by "diff --format json". Sizes are
+// disk usage, or apparent sizes when both scans were made that way.
+type Diff struct {
+    SchemaVersion int            `json:"schema_version"`
+    From          SnapshotInfo   `json:"from"`
+    To            SnapshotInfo   `json:"to"`
+    ApparentSize  bool           `json:"apparent_size"`
+    ScannedBefore int64          `json:"scanned_before"`
+    ScannedAfter  int64          `json:"scanned_after"`
+    Grown         []PathDelta    `json:"grown"`
+    NewFiles      []PathDelta    `json:"new_files"`
+    Deleted       []PathDelta    `json:"deleted"`
+    Types         []TypeDelta    `json:"types"`
+    Mounts        []MountDelta   `json:"mounts"`
+}
+
+// DiffRow is one line of a diff in the flat formats (NDJSON, CSV and
+// TSV). Kind is "grown", "new", "deleted", "type" or "mount"; Path holds
+// the finding type or mount point for the last two.
+type DiffRow struct {
+    Kind   string `json:"kind"`
+    Path   string `json:"path"`
+    IsDir  bool   `json:"is_dir"`
+    Before int64  `json:"before"`
+    After  int64  `json:"after"`
+    Delta  int64  `json:"delta"`
+}
+
+// ScannedDelta is the change in the bytes scanned.
+func (d *Diff) ScannedDelta() int64 {
+    return d.ScannedAfter - d.ScannedBefore
+}
+
+// Rows flattens the diff into rows, section by section.
+func (d *Diff) Rows() []DiffRow {
+    var rows []DiffRow
+    add := func(kind string, deltas []PathDelta) {
+        for _, p := range deltas {
+            rows = append(rows, DiffRow{kind, p.Path, p.IsDir, p.Before, p.After, p.Delta})
+        }
+    }
+    add("grown", d.Grown)
+    add("new", d.NewFiles)
+    add("deleted", d.Deleted)
+    for _, t := range d.Types {
+        rows = append(rows, DiffRow{"type", string(t.Type), false, t.Before, t.After, t.Delta})
+    }
+    for _, m := range d.Mounts {
+        rows = append(rows, DiffRow{"mount", m.MountPoint, true, m.Before, m.After, m.Delta})
+    }
+    return rows
+}
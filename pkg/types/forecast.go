@@ -0,0 +1,42 @@
+package types
+
+import (
+    "time"
+)
+
+// Trend is a straight line fitted to sizes over time. The growth range is
+// the 95% confidence interval of the rate and is only known with three or
+// more samples.
+type Trend struct {
+    Samples     int       `json:"samples"`
+    Since       time.Time `json:"since"`
+    BytesPerDay float64   `json:"bytes_per_day"`
+    LowPerDay   float64   `json:"low_bytes_per_day"`
+    HighPerDay  float64   `json:"high_bytes_per_day"`
+}
+
+// MountForecast projects when a filesystem fills up at its current rate
+// of growth. FullAt is only set when the filesystem is growing; FullEarliest
+// and FullLatest bound it, FullLatest being unset when the filesystem may
+// not be growing at all.
+type MountForecast struct {
+    MountPoint   string      `json:"mount_point"`
+    TotalBytes   int64       `json:"total_bytes"`
+    UsedBytes    int64       `json:"used_bytes"`
+    FreeBytes    int64       `json:"free_bytes"`
+    Trend        Trend       `json:"trend"`
+    DaysLeft     *float64    `json:"days_until_full,omitempty"`
+    FullAt       *time.Time  `json:"full_at,omitempty"`
+    FullEarliest *time.Time  `json:"full_earliest,omitempty"`
+    FullLatest   *time.Time  `json:"full_latest,omitempty"`
+    Drivers      []DirGrowth `json:"drivers"`
+}
+
+// DirGrowth is a directory driving the growth of its filesystem. Share is
+// its part of the filesystem's growth, between 0 and 1.
+type DirGrowth struct {
+    Path  string  `json:"path"`
+    Size  int64   `json:"size"`
+    Trend Trend   `json:"trend"`
+    Share float64 `json:"share"`
+}
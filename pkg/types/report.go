@@ -0,0 +1,43 @@
+package types
+
+import (
+    "time"
+)
+
+// SchemaVersion is the version of the JSON and NDJSON output. It changes
+// whenever a field is renamed, removed or changes meaning; new fields may
+// be added without a change.
+const SchemaVersion = 1
+
+// Report is everything a scan produced, as written by --format json.
+type Report struct {
+    SchemaVersion int               `json:"schema_version"`
+    Scan          ScanInfo          `json:"scan"`
+    Summary       Summary           `json:"summary"`
+    Mounts        []MountSummary    `json:"mounts"`
+    Findings      []ScanResult      `json:"findings"`
+    Errors        []string          `json:"errors"`
+    Tree          []DirNode         `json:"tree,omitempty"`
+    // Forecast is only present when enough snapshots were kept
+    Forecast      []MountForecast   `json:"forecast,omitempty"`
+    // Containers is only present when Docker's storage was analyzed
+    Containers    *ContainerStorage `json:"containers,omitempty"`
+}
+
+// ScanInfo describes how and where a scan ran.
+type ScanInfo struct {
+    Version      string    `json:"version"`
+    Host         string    `json:"host"`
+    Roots        []string  `json:"roots"`
+    StartedAt    time.Time `json:"started_at"`
+    Duration     float64   `json:"duration_seconds"`
+    Quick        bool      `json:"quick"`
+    ApparentSize bool      `json:"apparent_size"`
+    // Incomplete is set when the scan timeout stopped the scan early
+    Incomplete   bool      `json:"incomplete,omitempty"`
+}
+
+// Elapsed returns the scan duration.
+func (s ScanInfo) Elapsed() time.Duration {
+    return time.Duration(s.Duration * float64(time.Second))
+}
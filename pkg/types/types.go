@@ -8,16 +8,28 @@ import (
 type FileType string
 
 const (
-    TypeFile      FileType = "file"
-    TypeDirectory FileType = "directory"
-    TypeLog       FileType = "log"
-    TypeCache     FileType = "cache"
-    TypeTemp      FileType = "temp"
-    TypeBackup    FileType = "backup"
-    TypeDuplicate FileType = "duplicate"
-    TypeOrphan    FileType = "orphan"
+    TypeFile        FileType = "file"
+    TypeDirectory   FileType = "directory"
+    TypeLog         FileType = "log"
+    TypeCache       FileType = "cache"
+    TypeTemp        FileType = "temp"
+    TypeBackup      FileType = "backup"
+    TypeDuplicate   FileType = "duplicate"
+    TypeOrphan      FileType = "orphan"
+    TypeNodeModules FileType = "node_modules"
+    TypePythonEnv   FileType = "python_env"
+    TypePycache     FileType = "pycache"
+    TypeDocker      FileType = "docker"
+    TypeJournal     FileType = "journal"
 )
 
+// FileTypes lists every type a result can have.
+var FileTypes = []FileType{
+    TypeFile, TypeDirectory, TypeLog, TypeCache, TypeTemp, TypeBackup,
+    TypeDuplicate, TypeOrphan, TypeNodeModules, TypePythonEnv, TypePycache,
+    TypeDocker, TypeJournal,
+}
+
 type RiskLevel string
 
 const (
@@ -35,34 +47,107 @@ const (
 )
 
 type FileInfo struct {
-    Path          string
-    Size          int64
-    IsDir         bool
-    Mode          os.FileMode
-    ModTime       time.Time
-    AccessTime    time.Time
-    UID           uint32
-    GID           uint32
-    HardLinks     uint64
-    Inode         uint64
+    Path          string      `json:"path"`
+    Size          int64       `json:"size"`
+    Allocated     int64       `json:"allocated"`
+    IsDir         bool        `json:"is_dir"`
+    Mode          os.FileMode `json:"mode"`
+    ModTime       time.Time   `json:"mod_time"`
+    AccessTime    time.Time   `json:"access_time"`
+    ChangeTime    time.Time   `json:"change_time"`
+    UID           uint32      `json:"uid"`
+    GID           uint32      `json:"gid"`
+    HardLinks     uint64      `json:"hard_links"`
+    Inode         uint64      `json:"inode"`
+    Device        uint64      `json:"device"`
+}
+
+// Usage returns the bytes the entry occupies on disk, or its apparent size
+// when apparent is set (like du --apparent-size).
+func (f FileInfo) Usage(apparent bool) int64 {
+    if apparent {
+        return f.Size
+    }
+    return f.Allocated
+}
+
+// SharesData reports whether other hard links keep the entry's data alive
+// after this path is removed.
+func (f FileInfo) SharesData() bool {
+    return !f.IsDir && f.HardLinks > 1
+}
+
+// DirSummary describes a directory together with everything below it.
+// MaxDepth is the depth of the deepest entry relative to the directory.
+type DirSummary struct {
+    Info         FileInfo
+    TotalSize    int64
+    Allocated    int64
+    FileCount    int64
+    DirCount     int64
+    MaxDepth     int
+    NewestMod    time.Time
+    NewestAccess time.Time
+}
+
+// DirNode is a directory in the outline of a scan tree kept in reports,
+// with its largest subdirectories.
+type DirNode struct {
+    Path      string    `json:"path"`
+    Size      int64     `json:"size"`
+    Allocated int64     `json:"allocated"`
+    Files     int64     `json:"files"`
+    Children  []DirNode `json:"children,omitempty"`
 }
 
+// Flag qualifies a finding without changing its classification.
+type Flag string
+
+const (
+    FlagHardLinked         Flag = "hard-linked"
+    FlagAtimeUnreliable    Flag = "atime-unreliable"
+    // The environment's interpreter is gone, so it cannot run any more
+    FlagInterpreterMissing Flag = "interpreter-missing"
+)
+
 type ScanResult struct {
-    Info           FileInfo
-    Type           FileType
-    RiskLevel      RiskLevel
-    Recommendation Recommendation
-    Reason         string
-    DuplicateGroup string
-    AgeDays        int
+    Info           FileInfo       `json:"file"`
+    Type           FileType       `json:"type"`
+    RiskLevel      RiskLevel      `json:"risk"`
+    Recommendation Recommendation `json:"recommendation"`
+    Reason         string         `json:"reason,omitempty"`
+    DuplicateGroup string         `json:"duplicate_group,omitempty"`
+    AgeDays        int            `json:"age_days"`
+    Flags          []Flag         `json:"flags,omitempty"`
+}
+
+// IsFinding reports whether the result asks for any action, as opposed to
+// files that were scanned and found fine.
+func (r ScanResult) IsFinding() bool {
+    return r.Recommendation != RecKeep && r.RiskLevel != RiskSafe
+}
+
+// MountSummary totals the scanned data found on one mounted filesystem
+// next to the filesystem's own capacity.
+type MountSummary struct {
+    MountPoint   string `json:"mount_point"`
+    FSType       string `json:"fs_type"`
+    Source       string `json:"source"`
+    ScannedBytes int64  `json:"scanned_bytes"`
+    ScannedFiles int64  `json:"scanned_files"`
+    TotalBytes   int64  `json:"total_bytes"`
+    UsedBytes    int64  `json:"used_bytes"`
+    FreeBytes    int64  `json:"free_bytes"`
 }
 
+// Summary totals a scan. Sizes are disk usage, or apparent sizes when the
+// scan was made with apparent_size.
 type Summary struct {
-    TotalScannedBytes   int64
-    TotalScannedFiles   int64
-    TotalScannedDirs    int64
-    PotentialCleanup    int64
-    CriticalRiskCount   int64
-    CautionRiskCount    int64
-    ScanDuration       time.Duration
+    TotalScannedBytes   int64 `json:"total_scanned_bytes"`
+    TotalScannedFiles   int64 `json:"total_scanned_files"`
+    TotalScannedDirs    int64 `json:"total_scanned_dirs"`
+    PotentialCleanup    int64 `json:"potential_cleanup_bytes"`
+    CriticalRiskCount   int64 `json:"critical_count"`
+    CautionRiskCount    int64 `json:"caution_count"`
+    PermissionErrors    int64 `json:"permission_errors"`
 }
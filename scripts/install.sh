@@ -163,24 +163,27 @@ install_config() {
     SAMPLE_CONFIG="# shuru-hoja configuration
 # Production-safe filesystem analyzer
 
+[general]
 # Scanning options
 max_workers = 100
 skip_paths = /proc,/sys,/dev,/run
 
+[detection]
 # Detection thresholds
 log_file_age_days = 30
 orphan_dir_age_days = 90
 orphan_dir_min_size_gb = 1
-cache_dir_min_size_mb = 100
+cache_min_size_mb = 100
 duplicate_min_size_mb = 10
 
-# Risk levels
+[risk_assessment]
+# Findings at least this large are critical
 critical_size_gb = 10
-caution_size_gb = 1
 
+[output]
 # Output options
-output_format = table
-color_output = true
+format = table
+color = true
 max_results = 50
 "
     